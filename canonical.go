@@ -0,0 +1,205 @@
+package rmap
+
+import (
+	"bytes"
+	"crypto"
+	_ "crypto/sha256" // register SHA-224 and SHA-256 for HashWith
+	_ "crypto/sha512" // register SHA-384 and SHA-512 for HashWith
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+// CanonicalBytes returns RFC 8785 JSON Canonicalization Scheme (JCS) form of Rmap
+// Object keys are sorted by UTF-16 code units, numbers are formatted as in ECMAScript (IEEE 754 double) and strings use minimal escaping
+// Output does not depend on Go types held in Mapa, so it is suitable for hashing and signing
+func (r Rmap) CanonicalBytes() ([]byte, error) {
+	return CanonicalJSON(r)
+}
+
+func (r Rmap) MustCanonicalBytes() []byte {
+	byt, err := r.CanonicalBytes()
+	if err != nil {
+		panic(err)
+	}
+
+	return byt
+}
+
+// CanonicalJSON returns RFC 8785 canonical JSON form of any value, see Rmap.CanonicalBytes()
+func CanonicalJSON(value interface{}) ([]byte, error) {
+	byt, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "json.Marshal() failed")
+	}
+
+	// decode into generic form, numbers are kept as json.Number to be formatted later
+	dec := json.NewDecoder(bytes.NewReader(byt))
+	dec.UseNumber()
+
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, errors.Wrapf(err, "dec.Decode() failed")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeCanonical(buf, generic); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// HashWith returns hash of canonical form of Rmap computed by alg
+// Implementation of alg must be linked into binary, SHA-2 family and BLAKE2b are always available
+func (r Rmap) HashWith(alg crypto.Hash) ([]byte, error) {
+	if !alg.Available() {
+		return nil, fmt.Errorf("hash algorithm: %s is not available", alg)
+	}
+
+	byt, err := r.CanonicalBytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "r.CanonicalBytes() failed")
+	}
+
+	hasher := alg.New()
+	hasher.Write(byt)
+	return hasher.Sum(nil), nil
+}
+
+func (r Rmap) MustHashWith(alg crypto.Hash) []byte {
+	sum, err := r.HashWith(alg)
+	if err != nil {
+		panic(err)
+	}
+
+	return sum
+}
+
+func writeCanonical(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case string:
+		writeCanonicalString(buf, v)
+	case json.Number:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil {
+			return fmt.Errorf("number: %s cannot be represented as IEEE 754 double", v)
+		}
+
+		formatted, err := formatCanonicalNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(formatted)
+	case []interface{}:
+		buf.WriteByte('[')
+		for index, elem := range v {
+			if index > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return lessUTF16(keys[i], keys[j])
+		})
+
+		buf.WriteByte('{')
+		for index, key := range keys {
+			if index > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected type: %T in canonical JSON", value)
+	}
+
+	return nil
+}
+
+// formatCanonicalNumber formats number like ECMAScript Number.prototype.toString(), as required by RFC 8785
+func formatCanonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("number: %v is not valid JSON number", f)
+	}
+
+	if f == 0 {
+		// also handles negative zero
+		return "0", nil
+	}
+
+	abs := math.Abs(f)
+	if abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+
+	// Go uses at least two digits in exponent (1e-07), ECMAScript uses minimal count (1e-7)
+	formatted := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exponent, _ := strings.Cut(formatted, "e")
+	sign, digits := exponent[:1], strings.TrimLeft(exponent[1:], "0")
+	return mantissa + "e" + sign + digits, nil
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// lessUTF16 compares strings by their UTF-16 code units
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for index := 0; index < len(ua) && index < len(ub); index++ {
+		if ua[index] != ub[index] {
+			return ua[index] < ub[index]
+		}
+	}
+
+	return len(ua) < len(ub)
+}
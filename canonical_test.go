@@ -0,0 +1,86 @@
+package rmap
+
+import (
+	"crypto"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestCanonicalBytes(t *testing.T) {
+	// example from RFC 8785, section 3.2.2
+	rm := MustNewFromString(`{"numbers":[333333333.33333329,1E30,4.50,2e-3,0.000000000000000000000000001],"string":"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/","literals":[null,true,false]}`)
+	assert.Equal(t, `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`, string(rm.MustCanonicalBytes()))
+
+	// sorting by UTF-16 code units, RFC 8785, section 3.2.3
+	rm = MustNewFromString(`{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","\ud83d\ude00":"Emoji: Grinning Face","\u0080":"Control","\u00f6":"Latin Small Letter O With Diaeresis"}`)
+	assert.Equal(t, "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\",\"€\":\"Euro Sign\",\"😀\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}", string(rm.MustCanonicalBytes()))
+
+	// no HTML escaping
+	rm = NewFromMap(map[string]interface{}{"html": "<a&b>"})
+	assert.Equal(t, `{"html":"<a&b>"}`, string(rm.MustCanonicalBytes()))
+}
+
+func TestFormatCanonicalNumber(t *testing.T) {
+	cases := map[float64]string{
+		0:                       "0",
+		-1:                      "-1",
+		1e21:                    "1e+21",
+		1e20:                    "100000000000000000000",
+		1e-6:                    "0.000001",
+		1e-7:                    "1e-7",
+		5e-324:                  "5e-324",
+		1.7976931348623157e308:  "1.7976931348623157e+308",
+		-1.7976931348623157e308: "-1.7976931348623157e+308",
+		9007199254740992:        "9007199254740992",
+		295147905179352830000:   "295147905179352830000",
+		0.30000000000000004:     "0.30000000000000004",
+	}
+
+	for input, expected := range cases {
+		formatted, err := formatCanonicalNumber(input)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, formatted)
+	}
+}
+
+func TestHashIsStable(t *testing.T) {
+	fromJSON := MustNewFromString(`{"b":[1,2.0,{"c":"d"}],"a":1.50}`)
+	fromNumber := MustNewFromBytesWithOptions([]byte(`{"a":1.5000,"b":[1.0,2,{"c":"d"}]}`), UseNumber())
+	fromYAML, err := NewFromYAMLBytes([]byte("a: 1.5\nb:\n- 1\n- 2\n- c: d\n"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, fromJSON.Hash(), fromNumber.Hash())
+	assert.Equal(t, fromJSON.Hash(), fromYAML.Hash())
+	assert.Equal(t, fromJSON.Hash(), fromJSON.Copy().Hash())
+	assert.NotEqual(t, fromJSON.Hash(), NewEmpty().Hash())
+
+	hash := fromJSON.Hash()
+	assert.Equal(t, hash[:], fromYAML.MustHashWith(crypto.BLAKE2b_256))
+	assert.Len(t, fromJSON.MustHashWith(crypto.SHA256), 32)
+	assert.Len(t, fromJSON.MustHashWith(crypto.SHA512), 64)
+
+	_, err = fromJSON.HashWith(crypto.MD4)
+	assert.NotNil(t, err)
+}
+
+func TestHashInvalidDocument(t *testing.T) {
+	invalid := NewFromMap(map[string]interface{}{"nan": math.NaN()})
+
+	// Hash falls back to Bytes(), so it never panics
+	assert.NotPanics(t, func() { invalid.Hash() })
+	assert.Equal(t, blake2b.Sum256(invalid.Bytes()), invalid.Hash())
+
+	_, err := invalid.CanonicalHash()
+	assert.NotNil(t, err)
+
+	_, err = invalid.HashWith(crypto.BLAKE2b_256)
+	assert.NotNil(t, err)
+
+	valid := MustNewFromString(`{"a":1}`)
+	sum, err := valid.CanonicalHash()
+	assert.Nil(t, err)
+	assert.Equal(t, valid.Hash(), sum)
+}
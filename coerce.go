@@ -0,0 +1,133 @@
+package rmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// CoerceToSchema converts string, number and boolean values to type declared by schema, if conversion is lossless
+// Strings are converted to number (float64) if they are JSON numbers exactly representable by float64,
+// to integer if such number is integral and to boolean if they are "true" or "false". Numbers and booleans are converted to string
+// Value, which already has one of declared types or cannot be converted, is kept, so validation reports it
+// Traversal is the same as in ApplySchemaDefaults, Rmap is modified in-place
+func (r Rmap) CoerceToSchema(schema Rmap) error {
+	root, err := DefaultSchemaRegistry.bundle(schema, "")
+	if err != nil {
+		return err
+	}
+
+	return coerce(r, root)
+}
+
+// Coerce is CoerceToSchema with compiled schema
+func (v *Validator) Coerce(r Rmap) error {
+	return coerce(r, v.schema.Mapa)
+}
+
+func coerce(r Rmap, root map[string]interface{}) error {
+	if result, changed := applySchema(root, root, r.Mapa, schemaCoerce); changed {
+		obj, _ := asObject(result)
+		return r.replaceWith(NewFromMap(obj))
+	}
+
+	return nil
+}
+
+func schemaCoerce(value interface{}, schema map[string]interface{}) (interface{}, bool) {
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, elem := range t {
+			if name, ok := elem.(string); ok {
+				types = append(types, name)
+			}
+		}
+	}
+
+	for _, name := range types {
+		if schemaTypeMatches(value, name) {
+			return value, false
+		}
+	}
+
+	for _, name := range types {
+		if converted, ok := coerceValue(value, name); ok {
+			return converted, true
+		}
+	}
+
+	return value, false
+}
+
+func schemaTypeMatches(value interface{}, name string) bool {
+	switch name {
+	case "integer":
+		f, ok := asNumber(value)
+		return ok && f == math.Trunc(f)
+	default:
+		return jsonTypeOf(value) == name
+	}
+}
+
+func coerceValue(value interface{}, name string) (interface{}, bool) {
+	switch name {
+	case "number", "integer":
+		s, ok := value.(string)
+		if !ok || !jsonNumberPattern.MatchString(s) {
+			return nil, false
+		}
+
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, false
+		}
+
+		exact, err := decimal.NewFromString(s)
+		if err != nil || !exact.Equal(decimal.NewFromFloat(f)) {
+			return nil, false
+		}
+
+		if name == "integer" && f != math.Trunc(f) {
+			return nil, false
+		}
+
+		return f, true
+	case "boolean":
+		switch value {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+		return nil, false
+	case "string":
+		switch v := value.(type) {
+		case bool:
+			return strconv.FormatBool(v), true
+		case json.Number:
+			return string(v), true
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case float32:
+			return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+		}
+
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return fmt.Sprintf("%d", value), true
+		}
+		return nil, false
+	default:
+		return nil, false
+	}
+}
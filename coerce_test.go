@@ -0,0 +1,66 @@
+package rmap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoerceToSchema(t *testing.T) {
+	schema := MustNewFromString(`{
+		"type": "object",
+		"properties": {
+			"count": {"type": "integer"},
+			"price": {"type": "number"},
+			"active": {"type": "boolean"},
+			"zip": {"type": "string"},
+			"code": {"type": "string"},
+			"note": {"type": ["null", "integer", "string"]},
+			"items": {"type": "array", "items": {"$ref": "#/definitions/item"}}
+		},
+		"definitions": {"item": {"type": "object", "properties": {"qty": {"type": "integer"}}}}
+	}`)
+
+	rm := MustNewFromString(`{"count":"42","price":"0.10","active":"true","zip":12345,"code":true,"note":"7","items":[{"qty":"3"},{"qty":"x"}],"other":"1"}`)
+	assert.Nil(t, rm.CoerceToSchema(schema))
+	assert.Equal(t, `{"active":true,"code":"true","count":42,"items":[{"qty":3},{"qty":"x"}],"note":"7","other":"1","price":0.1,"zip":"12345"}`, rm.String())
+	assert.Nil(t, rm.DeleteJPtr("/items/1"))
+
+	validator, err := CompileSchema(schema)
+	assert.Nil(t, err)
+	assert.Nil(t, validator.Validate(rm))
+
+	rm = MustNewFromString(`{"count":"1","items":[{"qty":"2"}]}`)
+	assert.Nil(t, validator.Coerce(rm))
+	assert.Equal(t, `{"count":1,"items":[{"qty":2}]}`, rm.String())
+}
+
+func TestCoerceValue(t *testing.T) {
+	for _, tc := range []struct {
+		value    interface{}
+		name     string
+		expected interface{}
+		ok       bool
+	}{
+		{"42", "integer", float64(42), true},
+		{"-1e2", "integer", float64(-100), true},
+		{"4.5", "integer", nil, false},
+		{"042", "integer", nil, false},
+		{" 42", "number", nil, false},
+		{"12345678901234567891", "number", nil, false},
+		{"1e400", "number", nil, false},
+		{"0.1", "number", 0.1, true},
+		{"True", "boolean", nil, false},
+		{"false", "boolean", false, true},
+		{1.5, "string", "1.5", true},
+		{int64(9007199254740993), "string", "9007199254740993", true},
+		{json.Number("1.50"), "string", "1.50", true},
+		{false, "number", nil, false},
+		{"x", "null", nil, false},
+	} {
+		converted, ok := coerceValue(tc.value, tc.name)
+		assert.Equal(t, tc.ok, ok, "%v to %s", tc.value, tc.name)
+		assert.Equal(t, tc.expected, converted, "%v to %s", tc.value, tc.name)
+	}
+}
@@ -0,0 +1,127 @@
+package rmap
+
+import (
+	"reflect"
+)
+
+var rmapType = reflect.TypeOf(Rmap{})
+
+// Copy returns deep copy of Rmap, Go types of all values are preserved
+// Maps (including Rmap) and slices are copied recursively, other values (decimal.Decimal, time.Time, pointers, structs) are copied shallowly
+func (r Rmap) Copy() Rmap {
+	if r.Mapa == nil {
+		return NewEmpty()
+	}
+
+	return NewFromMap(copyMap(r.Mapa))
+}
+
+// replaceWith replaces content of Rmap in-place, so change is visible to all copies of Rmap value
+// Zero value Rmap{} has no map to modify, ErrNilMapa is returned
+func (r Rmap) replaceWith(other Rmap) error {
+	if r.Mapa == nil {
+		return ErrNilMapa
+	}
+
+	for key := range r.Mapa {
+		delete(r.Mapa, key)
+	}
+
+	for key, value := range other.Mapa {
+		r.Mapa[key] = value
+	}
+
+	return nil
+}
+
+func copyMap(mapa map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(mapa))
+	for key, value := range mapa {
+		out[key] = DeepCopy(value)
+	}
+	return out
+}
+
+// DeepCopy returns deep copy of any value stored in Rmap, see Rmap.Copy()
+func DeepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, float64, int, int64, struct{}:
+		return v
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		return copyMap(v)
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		out := make([]interface{}, len(v))
+		for index, elem := range v {
+			out[index] = DeepCopy(elem)
+		}
+		return out
+	case Rmap:
+		if v.Mapa == nil {
+			return v
+		}
+		return NewFromMap(copyMap(v.Mapa))
+	case []Rmap:
+		if v == nil {
+			return v
+		}
+		out := make([]Rmap, len(v))
+		for index, elem := range v {
+			out[index] = DeepCopy(elem).(Rmap)
+		}
+		return out
+	}
+
+	return copyReflect(reflect.ValueOf(value)).Interface()
+}
+
+func copyReflect(rv reflect.Value) reflect.Value {
+	if rv.Type() == rmapType {
+		return reflect.ValueOf(DeepCopy(rv.Interface()))
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv
+		}
+
+		out := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), copyReflect(iter.Value()))
+		}
+		return out
+	case reflect.Slice:
+		if rv.IsNil() {
+			return rv
+		}
+
+		out := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for index := 0; index < rv.Len(); index++ {
+			out.Index(index).Set(copyReflect(rv.Index(index)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(rv.Type()).Elem()
+		for index := 0; index < rv.Len(); index++ {
+			out.Index(index).Set(copyReflect(rv.Index(index)))
+		}
+		return out
+	case reflect.Interface:
+		if rv.IsNil() {
+			return rv
+		}
+
+		out := reflect.New(rv.Type()).Elem()
+		out.Set(reflect.ValueOf(DeepCopy(rv.Interface())))
+		return out
+	default:
+		return rv
+	}
+}
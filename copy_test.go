@@ -0,0 +1,90 @@
+package rmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyPreservesTypes(t *testing.T) {
+	now := time.Now()
+	original := NewFromMap(map[string]interface{}{
+		"int":     42,
+		"decimal": decimal.RequireFromString("1.23"),
+		"time":    now,
+		"set":     struct{}{},
+		"nested":  NewFromMap(map[string]interface{}{"key": "value"}),
+		"rmaps":   []Rmap{NewFromMap(map[string]interface{}{"a": 1})},
+		"strings": []string{"a", "b"},
+		"strmap":  map[string]string{"k": "v"},
+		"array":   []interface{}{map[string]interface{}{"deep": true}},
+		"null":    nil,
+	})
+
+	copied := original.Copy()
+	assert.Equal(t, original, copied)
+	assert.IsType(t, 42, copied.Mapa["int"])
+	assert.IsType(t, decimal.Decimal{}, copied.Mapa["decimal"])
+	assert.IsType(t, time.Time{}, copied.Mapa["time"])
+	assert.IsType(t, struct{}{}, copied.Mapa["set"])
+	assert.IsType(t, Rmap{}, copied.Mapa["nested"])
+	assert.IsType(t, []Rmap{}, copied.Mapa["rmaps"])
+}
+
+func TestCopyIsIndependent(t *testing.T) {
+	original := NewFromMap(map[string]interface{}{
+		"nested":  NewFromMap(map[string]interface{}{"key": "value"}),
+		"rmaps":   []Rmap{NewFromMap(map[string]interface{}{"a": 1})},
+		"strings": []string{"a"},
+		"strmap":  map[string]string{"k": "v"},
+		"array":   []interface{}{map[string]interface{}{"deep": true}},
+	})
+
+	copied := original.Copy()
+	copied.MustSetJPtr("/nested/key", "changed")
+	copied.MustSetJPtr("/rmaps/0/a", 2)
+	copied.MustSetJPtr("/strings/0", "changed")
+	copied.MustSetJPtr("/strmap/k", "changed")
+	copied.MustSetJPtr("/array/0/deep", false)
+
+	assert.Equal(t, "value", original.MustGetJPtr("/nested/key"))
+	assert.Equal(t, 1, original.MustGetJPtr("/rmaps/0/a"))
+	assert.Equal(t, "a", original.MustGetJPtr("/strings/0"))
+	assert.Equal(t, "v", original.MustGetJPtr("/strmap/k"))
+	assert.Equal(t, true, original.MustGetJPtr("/array/0/deep"))
+}
+
+func benchmarkDocument() Rmap {
+	items := make([]interface{}, 0, 100)
+	for i := 0; i < 100; i++ {
+		items = append(items, map[string]interface{}{
+			"id":    float64(i),
+			"name":  "item",
+			"tags":  []interface{}{"a", "b", "c"},
+			"price": map[string]interface{}{"amount": "10.00", "currency": "EUR"},
+		})
+	}
+
+	return NewFromMap(map[string]interface{}{"items": items})
+}
+
+func BenchmarkCopy(b *testing.B) {
+	rm := benchmarkDocument()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rm.Copy()
+	}
+}
+
+// BenchmarkCopyJSON measures previous implementation of Copy (JSON round-trip)
+func BenchmarkCopyJSON(b *testing.B) {
+	rm := benchmarkDocument()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = NewFromBytes(rm.Bytes())
+	}
+}
@@ -2,142 +2,322 @@ package rmap
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
-	"sort"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
-//RmapsToCSV takes multiple Rmap instances and returns as CSV bytes with header
-//nested keys are stored as l1.l2.l3
-func RmapsToCSV(rmaps []Rmap, separator string) ([]byte, error) {
-	header := map[string]interface{}{}
-	//Get header from first element
-	collectKeys(rmaps[0], nil, &header)
-	//Get sorted header keys
-	headerKeys := NewFromMap(header).KeysSliceString()
-	sort.Strings(headerKeys)
-	output := bytes.Buffer{}
+// CSVOption configures conversion of Rmaps to CSV
+type CSVOption func(*csvOptions)
 
-	//write sorted header to csv
-	output.Write(writeHeader(headerKeys, separator))
-	output.WriteString("\n")
+type csvOptions struct {
+	columns        []string
+	fill           string
+	inferRows      int
+	arrayMode      ArrayMode
+	arrayDelimiter string
+	arrays         bool // set by WithArrayMode
+	unflatten      bool // set by WithArrayMode and WithNestedColumns
+	rawCells       bool // cells are stored as they are, used by NewSliceFromCsv without options
+	separator      string
+	inferTypes     bool
+	stringsOnly    bool
+	schema         *Rmap
+}
 
-	for _, rm := range rmaps {
-		//each row starts with copy of header with all values set to struct{}
-		row := NewFromMap(header).Copy()
+// ArrayMode selects, how arrays are stored in CSV
+type ArrayMode int
 
-		//fill row with values
-		if err := collectValues(rm, nil, &row.Mapa); err != nil {
-			return nil, err
-		}
+// Without WithArrayMode, arrays are written formatted by fmt and column names and cells are not escaped
+const (
+	// ArrayJSON stores array in one cell as JSON
+	// String cells starting with [ or \ are prefixed with \, so they are not confused with arrays
+	ArrayJSON ArrayMode = iota
+	// ArrayIndexed stores every element in own column named by its index, for example items.0.name
+	ArrayIndexed
+	// ArrayJoined stores scalar elements joined by delimiter in one cell, column is named with suffix [], for example tags[]
+	ArrayJoined
+)
+
+// default delimiter of elements in ArrayJoined mode
+const defaultArrayDelimiter = "|"
+
+// separator of tokens in column names
+const csvPathSeparator = "."
+
+// prefix of string cells, which would be read as JSON array in ArrayJSON mode
+const csvStringEscape = `\`
+
+// suffix of column name with joined array
+const joinedArraySuffix = "[]"
+
+// WithColumns sets columns of CSV in given order, values of other keys are not written
+// By default, columns are union of keys of all rows, sorted
+func WithColumns(columns ...string) CSVOption {
+	return func(o *csvOptions) {
+		o.columns = columns
+	}
+}
+
+// WithFillValue sets value of cells, which are missing in row (default is empty string)
+func WithFillValue(fill string) CSVOption {
+	return func(o *csvOptions) {
+		o.fill = fill
+	}
+}
+
+// WithArrayMode sets, how arrays are stored in CSV, column names are escaped like in Flatten
+// When used with NewSliceFromCsv, dotted columns are rebuilt into nested objects and arrays are decoded by mode
+func WithArrayMode(mode ArrayMode) CSVOption {
+	return func(o *csvOptions) {
+		o.arrayMode = mode
+		o.arrays = true
+		o.unflatten = true
+	}
+}
 
-		//generate bytes for one row, sorted by header keys
-		rowBytes, err := writeValues(row, headerKeys, separator)
-		if err != nil {
+// WithNestedColumns escapes column names like Flatten, when writing, and rebuilds dotted columns into nested objects, when reading
+// Arrays are not decoded, see WithArrayMode, which implies this option
+func WithNestedColumns() CSVOption {
+	return func(o *csvOptions) {
+		o.unflatten = true
+	}
+}
+
+// WithArrayDelimiter sets delimiter of elements in ArrayJoined mode (default is |)
+func WithArrayDelimiter(delimiter string) CSVOption {
+	return func(o *csvOptions) {
+		o.arrayDelimiter = delimiter
+	}
+}
+
+func newCSVOptions(opts []CSVOption) csvOptions {
+	options := csvOptions{inferRows: defaultCSVInferRows, arrayDelimiter: defaultArrayDelimiter, separator: ","}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+func (o csvOptions) validateArrays() error {
+	if o.arrayMode == ArrayJoined && o.arrayDelimiter == "" {
+		return errors.New("array delimiter must not be empty")
+	}
+
+	return nil
+}
+
+// RmapsToCSV takes multiple Rmap instances and returns them as RFC 4180 CSV bytes with header
+// Nested keys are stored as l1.l2.l3, arrays are stored by WithArrayMode, separator must be a single character
+// Without WithArrayMode, column names and cells are not escaped and arrays are formatted by fmt
+// Fields containing separator, quotes or newlines are quoted, null is written as empty cell
+// CSV has no types and empty objects have no columns, so reading CSV back with the same options restores strings only, null and empty objects are lost
+func RmapsToCSV(rmaps []Rmap, separator string, opts ...CSVOption) ([]byte, error) {
+	output := bytes.Buffer{}
+
+	// all rows are known, so columns are inferred from all of them
+	opts = append([]CSVOption{WithInferRows(len(rmaps))}, opts...)
+	enc, err := NewCSVEncoder(&output, separator, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rm := range rmaps {
+		if err := enc.Encode(rm); err != nil {
 			return nil, err
 		}
+	}
 
-		output.Write(rowBytes)
-		output.WriteString("\n")
+	if err := enc.Flush(); err != nil {
+		return nil, err
 	}
 
 	return output.Bytes(), nil
 }
 
-func writeHeader(keys []string, separator string) []byte {
-	return []byte(strings.Join(keys, separator))
+func csvSeparator(separator string) (rune, error) {
+	comma, size := utf8.DecodeRuneInString(separator)
+	if size == 0 || size != len(separator) || comma == utf8.RuneError || comma == '"' || comma == '\r' || comma == '\n' {
+		return 0, errors.Errorf("invalid CSV separator: %q, it must be a single character", separator)
+	}
+
+	return comma, nil
 }
 
-func writeValues(input Rmap, headerKeys []string, separator string) ([]byte, error) {
-	rowData := make([]string, len(headerKeys))
+// flattenCSV stores all values of nested objects into row under keys in format a.b.c
+// Keys are escaped like in Flatten, if options.unflatten is set, empty nested objects have no columns
+func flattenCSV(obj map[string]interface{}, path []string, row map[string]interface{}, options csvOptions) error {
+	tokens := make([]flatToken, 0, len(path))
+	for _, key := range path {
+		tokens = append(tokens, flatToken{name: key, objectKey: true})
+	}
 
-	for idx, key := range headerKeys {
-		val, err := input.Get(key)
-		if err != nil {
-			return nil, err
+	indexed := options.arrays && options.arrayMode == ArrayIndexed
+	return walkFlat(obj, NewPointer(path...), tokens, indexed, func(path Pointer, tokens []flatToken, value interface{}) error {
+		if _, ok := asObject(value); ok {
+			return nil
+		}
+
+		column := strings.Join([]string(path), csvPathSeparator)
+		if options.unflatten {
+			column = joinFlatKey(tokens, csvPathSeparator, indexed)
+		}
+
+		arr, ok := asArray(value)
+		if !ok || !options.arrays {
+			if str, isStr := value.(string); isStr && options.arrays && options.arrayMode == ArrayJSON && (strings.HasPrefix(str, "[") || strings.HasPrefix(str, csvStringEscape)) {
+				// string cannot be confused with JSON array
+				value = csvStringEscape + str
+			}
+
+			row[column] = value
+			return nil
 		}
 
-		valS, isString := val.(string)
-		if isString {
-			if strings.Index(valS, separator) != -1 {
-				//, in string, wrap in ""
-				if strings.Index(valS, `"`) != -1 {
-					// " in string remove
-					valS = strings.Replace(valS, `"`, ``, -1)
+		switch options.arrayMode {
+		case ArrayIndexed:
+			// empty array has no columns
+		case ArrayJoined:
+			cells := make([]string, 0, len(arr))
+			for index, elem := range arr {
+				_, isObj := asObject(elem)
+				_, isArr := asArray(elem)
+				if isObj || isArr {
+					return errors.Errorf("JSONPointer: %s cannot be joined, only scalar elements are supported", path.Append(strconv.Itoa(index)))
 				}
 
-				rowData[idx] = `"` + valS + `"`
-			} else {
-				rowData[idx] = fmt.Sprintf("%v", val)
+				cell := csvCell(elem)
+				if strings.Contains(cell, options.arrayDelimiter) {
+					return errors.Errorf("JSONPointer: %s cannot be joined, it contains delimiter: %q", path.Append(strconv.Itoa(index)), options.arrayDelimiter)
+				}
+				cells = append(cells, cell)
+			}
+
+			row[column+joinedArraySuffix] = strings.Join(cells, options.arrayDelimiter)
+		default:
+			data, err := json.Marshal(arr)
+			if err != nil {
+				return errors.Wrapf(err, "json.Marshal() failed")
 			}
-		} else {
-			rowData[idx] = fmt.Sprintf("%v", val)
+
+			row[column] = string(data)
 		}
-	}
 
-	return []byte(strings.Join(rowData, separator)), nil
+		return nil
+	})
 }
 
-func collectValues(input Rmap, path []string, row *map[string]interface{}) error {
-	for k, v := range input.Mapa {
-		switch v.(type) {
-		case Rmap:
-			//nested Rmap, recurse
-			if err := collectValues(v.(Rmap), append(path, k), row); err != nil {
-				return err
+// unflattenCSV creates Rmap from CSV record, reverse of flattenCSV, columns are used as keys, unless options.unflatten is set
+// Cells equal to fill value are treated as missing, returned error is *CSVError without row
+func unflattenCSV(header, record []string, options csvOptions) (Rmap, error) {
+	root := map[string]interface{}{}
+	for index, column := range header {
+		cell := record[index]
+		if cell == options.fill {
+			continue
+		}
+
+		value := options.cellValue(cell)
+		switch {
+		case !options.arrays:
+		case options.arrayMode == ArrayJoined && strings.HasSuffix(column, joinedArraySuffix):
+			column = strings.TrimSuffix(column, joinedArraySuffix)
+			elems := []interface{}{}
+			if cell != "" {
+				for _, elem := range strings.Split(cell, options.arrayDelimiter) {
+					elems = append(elems, options.cellValue(elem))
+				}
 			}
-		case map[string]interface{}:
-			//nested map, recurse
-			if err := collectValues(NewFromMap(v.(map[string]interface{})), append(path, k), row); err != nil {
-				return err
+			value = elems
+		case options.arrayMode == ArrayJSON && strings.HasPrefix(cell, "["):
+			// cell, which is not JSON array, was not written by RmapsToCSV, it is kept as string
+			elems := []interface{}{}
+			if err := json.Unmarshal([]byte(cell), &elems); err == nil {
+				value = elems
 			}
-		default:
-			if err := processValue(v, append(path, k), row); err != nil {
-				return err
+		case options.arrayMode == ArrayJSON && strings.HasPrefix(cell, csvStringEscape):
+			value = strings.TrimPrefix(cell, csvStringEscape)
+		}
+
+		if !options.unflatten {
+			root[column] = value
+			continue
+		}
+
+		if _, err := setFlatPath(root, splitFlatKey(column, csvPathSeparator), flatValue(value), options.arrays && options.arrayMode == ArrayIndexed, len(header)); err != nil {
+			if err == errFlatConflict {
+				err = errors.New("value conflicts with other column")
 			}
+			return Rmap{}, &CSVError{Column: index + 1, Name: header[index], Err: err}
 		}
 	}
 
-	return nil
+	return NewFromMap(resolveFlatNulls(root).(map[string]interface{})), nil
 }
 
-func processValue(value interface{}, path []string, row *map[string]interface{}) error {
-	key := strings.Join(path, ".")
+// cellValue returns value of scalar cell, its type is inferred, if enabled
+func (o csvOptions) cellValue(cell string) interface{} {
+	if !o.inferTypes {
+		return cell
+	}
 
-	_, exists := (*row)[key]
-	if !exists {
-		return fmt.Errorf("unexpected key: %s, not found in header", key)
+	if cell == "" {
+		return nil
+	}
+
+	for _, name := range []string{"number", "boolean"} {
+		if value, ok := coerceValue(cell, name); ok {
+			return value
+		}
 	}
 
-	switch value.(type) {
+	return cell
+}
+
+// csvCell formats scalar value for CSV cell
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
 	case string:
-		(*row)[key] = strings.Replace(value.(string), "\n", "", -1)
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case decimal.Decimal:
+		return v.String()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
 	case float64:
-		(*row)[key] = value.(float64)
-	case int:
-		(*row)[key] = value.(int)
-	default:
-		//fallback
-		(*row)[key] = fmt.Sprintf("%v", value)
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
 	}
 
-	return nil
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d", value)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
 }
 
-//fill keys map with keys present in input
-//nested keys are returned in format a.b.c
+// fill keys map with keys present in input
+// nested keys are returned in format a.b.c
 func collectKeys(input Rmap, path []string, keys *map[string]interface{}) {
-	for k, v := range input.Mapa {
-		switch v.(type) {
-		case Rmap:
-			//nested Rmap, recurse
-			collectKeys(v.(Rmap), append(path, k), keys)
-		case map[string]interface{}:
-			//nested map, recurse
-			collectKeys(NewFromMap(v.(map[string]interface{})), append(path, k), keys)
-		default:
-			//anything else is just a key
-			(*keys)[strings.Join(append(path, k), ".")] = struct{}{}
-		}
+	row := map[string]interface{}{}
+	_ = flattenCSV(input.Mapa, path, row, newCSVOptions(nil))
+
+	for key := range row {
+		(*keys)[key] = struct{}{}
 	}
 }
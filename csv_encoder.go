@@ -0,0 +1,190 @@
+package rmap
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// TSVSeparator can be used as separator to write or read tab-separated values
+const TSVSeparator = "\t"
+
+// number of rows buffered to infer columns, when columns are not set by WithColumns
+const defaultCSVInferRows = 100
+
+// WithInferRows sets number of first rows, which are buffered by CSVEncoder to infer columns from union of their keys
+// It is ignored when WithColumns is used
+func WithInferRows(rows int) CSVOption {
+	return func(o *csvOptions) {
+		o.inferRows = rows
+	}
+}
+
+// CSVEncoder writes Rmaps as CSV rows to io.Writer one at a time
+// Columns are set by WithColumns, or inferred from the first rows (see WithInferRows), rows are buffered until then
+// Call Flush() after the last row
+type CSVEncoder struct {
+	writer   *csv.Writer
+	options  csvOptions
+	header   []string
+	columns  map[string]struct{}
+	buffered []map[string]interface{}
+	rows     int
+}
+
+// NewCSVEncoder creates CSVEncoder writing to w, separator must be a single character
+func NewCSVEncoder(w io.Writer, separator string, opts ...CSVOption) (*CSVEncoder, error) {
+	options := newCSVOptions(opts)
+	if err := options.validateArrays(); err != nil {
+		return nil, err
+	}
+
+	comma, err := csvSeparator(separator)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+
+	enc := &CSVEncoder{writer: writer, options: options}
+	if options.columns != nil {
+		if err := enc.writeHeader(options.columns); err != nil {
+			return nil, err
+		}
+	}
+
+	return enc, nil
+}
+
+// Encode writes r as one row
+// When columns are inferred, a row with key not present in inferred columns is rejected with error
+func (e *CSVEncoder) Encode(r Rmap) error {
+	e.rows++
+	row := map[string]interface{}{}
+	if err := flattenCSV(r.Mapa, nil, row, e.options); err != nil {
+		return errors.Wrapf(err, "row: %d", e.rows)
+	}
+
+	if e.header == nil {
+		e.buffered = append(e.buffered, row)
+		if len(e.buffered) < e.options.inferRows {
+			return nil
+		}
+
+		return e.inferHeader()
+	}
+
+	return e.writeRow(row, e.rows)
+}
+
+// Flush writes buffered rows and flushes underlying writer
+func (e *CSVEncoder) Flush() error {
+	if e.header == nil {
+		if err := e.inferHeader(); err != nil {
+			return err
+		}
+	}
+
+	e.writer.Flush()
+	if err := e.writer.Error(); err != nil {
+		return errors.Wrapf(err, "writer.Flush() failed")
+	}
+
+	return nil
+}
+
+// Columns returns header of CSV, it is nil until columns are known
+func (e *CSVEncoder) Columns() []string {
+	return e.header
+}
+
+// inferHeader writes header from union of keys of buffered rows, and writes buffered rows
+func (e *CSVEncoder) inferHeader() error {
+	union := map[string]struct{}{}
+	for _, row := range e.buffered {
+		for key := range row {
+			union[key] = struct{}{}
+		}
+	}
+
+	header := make([]string, 0, len(union))
+	for key := range union {
+		header = append(header, key)
+	}
+	sort.Strings(header)
+
+	if err := e.writeHeader(header); err != nil {
+		return err
+	}
+
+	buffered := e.buffered
+	e.buffered = nil
+
+	first := e.rows - len(buffered) + 1
+	for index, row := range buffered {
+		if err := e.writeRow(row, first+index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *CSVEncoder) writeHeader(header []string) error {
+	e.header = header
+	e.columns = make(map[string]struct{}, len(header))
+	for _, column := range header {
+		e.columns[column] = struct{}{}
+	}
+
+	if len(header) == 0 {
+		return nil
+	}
+
+	if err := e.writer.Write(header); err != nil {
+		return errors.Wrapf(err, "writer.Write() failed")
+	}
+
+	return nil
+}
+
+// writeRow writes row, number is its 1-based index used in errors
+func (e *CSVEncoder) writeRow(row map[string]interface{}, number int) error {
+	if e.options.columns == nil {
+		extra := []string{}
+		for key := range row {
+			if _, exists := e.columns[key]; !exists {
+				extra = append(extra, key)
+			}
+		}
+
+		if len(extra) > 0 {
+			sort.Strings(extra)
+			return errors.Errorf("row: %d has keys: %v, which are not in inferred columns", number, extra)
+		}
+	}
+
+	if len(e.header) == 0 {
+		return nil
+	}
+
+	record := make([]string, len(e.header))
+	for index, column := range e.header {
+		value, exists := row[column]
+		if !exists {
+			record[index] = e.options.fill
+			continue
+		}
+
+		record[index] = csvCell(value)
+	}
+
+	if err := e.writer.Write(record); err != nil {
+		return errors.Wrapf(err, "writer.Write() failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package rmap
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVEncoderColumns(t *testing.T) {
+	output := bytes.Buffer{}
+	enc, err := NewCSVEncoder(&output, TSVSeparator, WithColumns("id", "owner.name"))
+	assert.Nil(t, err)
+
+	// header is written immediately, rows are not buffered
+	assert.Nil(t, enc.Encode(MustNewFromString(`{"id":1,"owner":{"name":"a\tb"},"ignored":true}`)))
+	assert.Nil(t, enc.Encode(MustNewFromString(`{"id":2}`)))
+	assert.Nil(t, enc.Flush())
+
+	assert.Equal(t, "id\towner.name\n1\t\"a\tb\"\n2\t\n", output.String())
+}
+
+func TestCSVEncoderInfer(t *testing.T) {
+	output := bytes.Buffer{}
+	enc, err := NewCSVEncoder(&output, ",", WithInferRows(2), WithFillValue("-"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, enc.Encode(MustNewFromString(`{"a":1}`)))
+	assert.Nil(t, enc.Columns())
+	assert.Nil(t, enc.Encode(MustNewFromString(`{"b":2}`)))
+	assert.Equal(t, []string{"a", "b"}, enc.Columns())
+
+	assert.Nil(t, enc.Encode(MustNewFromString(`{"a":3,"b":4}`)))
+
+	err = enc.Encode(MustNewFromString(`{"a":5,"c":6}`))
+	assert.Equal(t, "row: 4 has keys: [c], which are not in inferred columns", err.Error())
+
+	assert.Nil(t, enc.Flush())
+	assert.Equal(t, "a,b\n1,-\n-,2\n3,4\n", output.String())
+}
+
+func TestCSVEncoderFlushBeforeInfer(t *testing.T) {
+	output := bytes.Buffer{}
+	enc, err := NewCSVEncoder(&output, ",")
+	assert.Nil(t, err)
+
+	assert.Nil(t, enc.Encode(MustNewFromString(`{"x":"y"}`)))
+	assert.Empty(t, output.String())
+
+	assert.Nil(t, enc.Flush())
+	assert.Equal(t, "x\ny\n", output.String())
+
+	_, err = NewCSVEncoder(&output, "")
+	assert.NotNil(t, err)
+}
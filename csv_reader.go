@@ -0,0 +1,144 @@
+package rmap
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// CSVError is returned, when CSV cell cannot be stored into Rmap
+// Use errors.As() to get row and column number
+type CSVError struct {
+	Row    int    // 1-based number of data row, header is not counted
+	Column int    // 1-based number of column
+	Name   string // name of column from header
+	Err    error
+}
+
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("row: %d: column: %s: %v", e.Row, e.Name, e.Err)
+}
+
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// WithSeparator sets separator of read CSV, it must be a single character (default is ,)
+func WithSeparator(separator string) CSVOption {
+	return func(o *csvOptions) {
+		o.separator = separator
+	}
+}
+
+// WithoutTypeInference makes NewSliceFromCSVReader store all cells as strings
+func WithoutTypeInference() CSVOption {
+	return func(o *csvOptions) {
+		o.stringsOnly = true
+	}
+}
+
+// WithCSVSchema makes NewSliceFromCSVReader convert cells to types declared by schema instead of inferring them, see CoerceToSchema
+func WithCSVSchema(schema Rmap) CSVOption {
+	return func(o *csvOptions) {
+		o.schema = &schema
+	}
+}
+
+// NewSliceFromCSVReader reads CSV with header, every non-empty line is one Rmap, columns are used as keys
+// WithNestedColumns rebuilds dotted columns into nested objects, WithArrayMode decodes arrays too
+// Cell types are inferred: empty cell is null, true and false are booleans and numbers exactly representable by float64 are float64,
+// other cells are strings. WithCSVSchema coerces string cells by schema instead, WithoutTypeInference keeps strings
+// Cells equal to fill value (see WithFillValue) are treated as missing, by default it is empty string, so empty cells are missing
+// Errors of cells are *CSVError with row and column
+func NewSliceFromCSVReader(reader io.Reader, opts ...CSVOption) ([]Rmap, error) {
+	options := newCSVOptions(opts)
+	options.inferTypes = !options.stringsOnly && options.schema == nil
+
+	return readCSV(reader, options)
+}
+
+// readCSV reads CSV with header, cells are decoded by unflattenCSV, unless options.rawCells is set
+func readCSV(reader io.Reader, options csvOptions) ([]Rmap, error) {
+	if err := options.validateArrays(); err != nil {
+		return nil, err
+	}
+
+	comma, err := csvSeparator(options.separator)
+	if err != nil {
+		return nil, err
+	}
+
+	var validator *Validator
+	if options.schema != nil {
+		validator, err = CompileSchema(*options.schema)
+		if err != nil {
+			return nil, errors.Wrapf(err, "CompileSchema() failed")
+		}
+	}
+
+	r := csv.NewReader(reader)
+	r.Comma = comma
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.Wrapf(err, "r.Read() failed")
+	}
+
+	out := []Rmap{}
+	row := 0
+
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, errors.Wrapf(err, "r.Read() failed")
+		}
+		row++
+
+		isEmpty := true
+		for _, cell := range record {
+			if cell != "" {
+				isEmpty = false
+				break
+			}
+		}
+
+		// only append non empty lines (contains at least one string value)
+		if isEmpty {
+			continue
+		}
+
+		if options.rawCells {
+			rm := NewEmpty()
+			for index, column := range header {
+				rm.Mapa[column] = record[index]
+			}
+
+			out = append(out, rm)
+			continue
+		}
+
+		rm, err := unflattenCSV(header, record, options)
+		if err != nil {
+			var cerr *CSVError
+			if errors.As(err, &cerr) {
+				cerr.Row = row
+			}
+			return nil, err
+		}
+
+		if validator != nil {
+			if err := validator.Coerce(rm); err != nil {
+				return nil, err
+			}
+		}
+
+		out = append(out, rm)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package rmap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSliceFromCSVReader(t *testing.T) {
+	input := "id;name;owner.name;owner.active;price;big;note\n" +
+		"1;\"a;b\";x;true;0.5;12345678901234567891;null\n" +
+		";;;;;;\n" +
+		"2;b;y;false;-1e3;;\n"
+
+	rows, err := NewSliceFromCSVReader(strings.NewReader(input), WithSeparator(";"), WithNestedColumns())
+	assert.Nil(t, err)
+	assert.Len(t, rows, 2)
+
+	// big number cannot be float64 exactly, so it is kept as string
+	assert.Equal(t, `{"big":"12345678901234567891","id":1,"name":"a;b","note":"null","owner":{"active":true,"name":"x"},"price":0.5}`, rows[0].String())
+	assert.Equal(t, `{"id":2,"name":"b","owner":{"active":false,"name":"y"},"price":-1000}`, rows[1].String())
+
+	// empty cell is null, when fill value marks missing cells
+	rows, err = NewSliceFromCSVReader(strings.NewReader("a,b,c\n,-,1\n"), WithFillValue("-"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":null,"c":1}`, rows[0].String())
+
+	rows, err = NewSliceFromCSVReader(strings.NewReader("a.b,c\n1,true\n"), WithoutTypeInference(), WithNestedColumns())
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":{"b":"1"},"c":"true"}`, rows[0].String())
+}
+
+func TestNewSliceFromCSVReaderForeign(t *testing.T) {
+	// CSV not written by this package, dotted columns, [ and \ are not interpreted without options
+	input := "title,path,a.b,a\n[draft] report,\\\\srv\\share,1,2\n"
+
+	rows, err := NewSliceFromCSVReader(strings.NewReader(input))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"title": "[draft] report", "path": `\\srv\share`, "a.b": float64(1), "a": float64(2)}, rows[0].Mapa)
+
+	rows, err = NewSliceFromCSVReader(strings.NewReader(input), WithoutTypeInference())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"title": "[draft] report", "path": `\\srv\share`, "a.b": "1", "a": "2"}, rows[0].Mapa)
+
+	// nested columns do not decode cells
+	rows, err = NewSliceFromCSVReader(strings.NewReader("title,path,a.b\n[draft] report,\\\\srv\\share,1\n"), WithNestedColumns())
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":{"b":1},"path":"\\\\srv\\share","title":"[draft] report"}`, rows[0].String())
+}
+
+func TestNewSliceFromCSVReaderSchema(t *testing.T) {
+	schema := MustNewFromString(`{"properties":{"zip":{"type":"string"},"qty":{"type":"integer"},"tags":{"type":"array","items":{"type":"number"}}}}`)
+
+	rows, err := NewSliceFromCSVReader(strings.NewReader("zip\tqty\ttags[]\n01234\t7\t1|2.5\n"), WithSeparator(TSVSeparator), WithCSVSchema(schema), WithArrayMode(ArrayJoined))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"qty":7,"tags":[1,2.5],"zip":"01234"}`, rows[0].String())
+
+	_, err = NewSliceFromCSVReader(strings.NewReader("a\n1\n"), WithCSVSchema(MustNewFromString(`{"type":5}`)))
+	assert.NotNil(t, err)
+}
+
+func TestNewSliceFromCSVReaderErrors(t *testing.T) {
+	_, err := NewSliceFromCSVReader(strings.NewReader("a,b\n1,2\n3,4\n\n5,6,7\n"))
+	assert.Equal(t, "r.Read() failed: record on line 5: wrong number of fields", err.Error())
+
+	_, err = NewSliceFromCSVReader(strings.NewReader("a,a.b\n,\n1,2\n"), WithFillValue("-"), WithNestedColumns())
+	var cerr *CSVError
+	assert.True(t, errors.As(err, &cerr))
+	assert.Equal(t, 2, cerr.Row)
+	assert.Equal(t, 2, cerr.Column)
+	assert.Equal(t, "a.b", cerr.Name)
+	assert.Equal(t, "row: 2: column: a.b: value conflicts with other column", err.Error())
+
+	_, err = NewSliceFromCSVReader(strings.NewReader("a\n1\n"), WithSeparator("ab"))
+	assert.NotNil(t, err)
+
+	_, err = NewSliceFromCSVReader(strings.NewReader(""))
+	assert.NotNil(t, err)
+}
@@ -2,6 +2,8 @@ package rmap
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,3 +44,149 @@ func TestCollectKeys(t *testing.T) {
 	assert.Equal(t, 6, len(keys))
 }
 
+func TestRmapsToCSV(t *testing.T) {
+	rmaps := []Rmap{
+		MustNewFromString(`{"name":"a,b","nested":{"x":1}}`),
+		MustNewFromString(`{"name":"say \"hi\"","extra":true,"nested":{"y":"line1\nline2"}}`),
+		MustNewFromString(`{"name":null,"nested":{"x":0.5}}`),
+	}
+
+	output, err := RmapsToCSV(rmaps, ",")
+	assert.Nil(t, err)
+	assert.Equal(t, "extra,name,nested.x,nested.y\n,\"a,b\",1,\ntrue,\"say \"\"hi\"\"\",,\"line1\nline2\"\n,,0.5,\n", string(output))
+}
+
+func TestRmapsToCSVOptions(t *testing.T) {
+	rmaps := []Rmap{
+		MustNewFromString(`{"a":1,"b":2}`),
+		MustNewFromString(`{"a":3}`),
+	}
+
+	output, err := RmapsToCSV(rmaps, ";", WithColumns("b", "a"), WithFillValue("N/A"))
+	assert.Nil(t, err)
+	assert.Equal(t, "b;a\n2;1\nN/A;3\n", string(output))
+
+	output, err = RmapsToCSV(nil, ",")
+	assert.Nil(t, err)
+	assert.Empty(t, output)
+
+	_, err = RmapsToCSV(rmaps, ",,")
+	assert.NotNil(t, err)
+
+	_, err = RmapsToCSV(rmaps, `"`)
+	assert.NotNil(t, err)
+}
+
+func csvRoundTrip(t *testing.T, rmaps []Rmap, opts ...CSVOption) (string, []Rmap) {
+	output, err := RmapsToCSV(rmaps, ",", opts...)
+	assert.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), "rows.csv")
+	assert.Nil(t, os.WriteFile(path, output, 0o600))
+
+	read, err := NewSliceFromCsv(path, opts...)
+	assert.Nil(t, err)
+
+	return string(output), read
+}
+
+func TestCSVArrays(t *testing.T) {
+	rmaps := []Rmap{
+		MustNewFromString(`{"id":"1","tags":["a","b"],"items":[{"name":"x"},{"name":"y","qty":"2"}]}`),
+		MustNewFromString(`{"id":"2","tags":["c"],"items":[{"name":"z"}]}`),
+	}
+
+	output, read := csvRoundTrip(t, rmaps, WithArrayMode(ArrayJSON))
+	assert.Equal(t, "id,items,tags\n1,\"[{\"\"name\"\":\"\"x\"\"},{\"\"name\"\":\"\"y\"\",\"\"qty\"\":\"\"2\"\"}]\",\"[\"\"a\"\",\"\"b\"\"]\"\n2,\"[{\"\"name\"\":\"\"z\"\"}]\",\"[\"\"c\"\"]\"\n", output)
+	assert.Equal(t, rmaps, read)
+
+	output, read = csvRoundTrip(t, rmaps, WithArrayMode(ArrayIndexed))
+	assert.Equal(t, "id,items.0.name,items.1.name,items.1.qty,tags.0,tags.1\n1,x,y,2,a,b\n2,z,,,c,\n", output)
+	assert.Equal(t, rmaps, read)
+
+	// joined mode supports scalar elements only
+	rmaps = []Rmap{
+		MustNewFromString(`{"id":"1","meta":{"tags":["a","b"]}}`),
+		MustNewFromString(`{"id":"2","meta":{"tags":[]}}`),
+	}
+
+	output, read = csvRoundTrip(t, rmaps, WithArrayMode(ArrayJoined), WithArrayDelimiter(";"), WithFillValue("-"))
+	assert.Equal(t, "id,meta.tags[]\n1,a;b\n2,\n", output)
+	assert.Equal(t, rmaps, read)
+}
+
+func TestCSVArraysErrors(t *testing.T) {
+	_, err := RmapsToCSV([]Rmap{MustNewFromString(`{"tags":["a|b"]}`)}, ",", WithArrayMode(ArrayJoined))
+	assert.Equal(t, `row: 1: JSONPointer: /tags/0 cannot be joined, it contains delimiter: "|"`, err.Error())
+
+	_, err = RmapsToCSV([]Rmap{MustNewFromString(`{"tags":[{"a":1}]}`)}, ",", WithArrayMode(ArrayJoined))
+	assert.Equal(t, "row: 1: JSONPointer: /tags/0 cannot be joined, only scalar elements are supported", err.Error())
+
+	_, err = RmapsToCSV(nil, ",", WithArrayMode(ArrayJoined), WithArrayDelimiter(""))
+	assert.NotNil(t, err)
+
+	path := filepath.Join(t.TempDir(), "rows.csv")
+	assert.Nil(t, os.WriteFile(path, []byte("a,a.b\n1,2\n"), 0o600))
+
+	_, err = NewSliceFromCsv(path, WithArrayMode(ArrayIndexed))
+	assert.Equal(t, "row: 1: column: a.b: value conflicts with other column", err.Error())
+
+	// without options, columns are kept as keys
+	read, err := NewSliceFromCsv(path)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1", "a.b": "2"}, read[0].Mapa)
+}
+
+func TestCSVEscapedColumns(t *testing.T) {
+	rmaps := []Rmap{MustNewFromString(`{"host.name":"a","meta":{"k.v":"b"}}`)}
+
+	output, read := csvRoundTrip(t, rmaps, WithArrayMode(ArrayJSON))
+	assert.Equal(t, "host\\.name,meta.k\\.v\na,b\n", output)
+	assert.Equal(t, rmaps, read)
+
+	output, read = csvRoundTrip(t, rmaps, WithNestedColumns())
+	assert.Equal(t, "host\\.name,meta.k\\.v\na,b\n", output)
+	assert.Equal(t, rmaps, read)
+}
+
+func TestCSVRoundTripLimits(t *testing.T) {
+	rmaps := []Rmap{
+		MustNewFromString(`{"id":1,"ok":true,"price":0.5,"none":null,"empty":{},"tags":[1,"x"],"s":"[1]","b":"\\x"}`),
+	}
+
+	output, read := csvRoundTrip(t, rmaps, WithArrayMode(ArrayJSON))
+	assert.Equal(t, "b,id,none,ok,price,s,tags\n\\\\x,1,,true,0.5,\\[1],\"[1,\"\"x\"\"]\"\n", output)
+	// strings starting with [ are not confused with arrays, scalars are strings, null and empty object are lost
+	assert.Equal(t, `{"b":"\\x","id":"1","ok":"true","price":"0.5","s":"[1]","tags":[1,"x"]}`, read[0].String())
+
+	// escaping is used only in ArrayJSON mode
+	output, read = csvRoundTrip(t, rmaps, WithArrayMode(ArrayIndexed))
+	assert.Equal(t, "b,id,none,ok,price,s,tags.0,tags.1\n\\x,1,,true,0.5,[1],1,x\n", output)
+	assert.Equal(t, `{"b":"\\x","id":"1","ok":"true","price":"0.5","s":"[1]","tags":["1","x"]}`, read[0].String())
+
+	// cell, which is not JSON array, is string
+	path := filepath.Join(t.TempDir(), "rows.csv")
+	assert.Nil(t, os.WriteFile(path, []byte("a,b\n[draft] report,[1\n"), 0o600))
+	read, err := NewSliceFromCsv(path, WithArrayMode(ArrayJSON))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "[draft] report", "b": "[1"}, read[0].Mapa)
+}
+
+func TestCSVWithoutArrayMode(t *testing.T) {
+	rmaps := []Rmap{
+		MustNewFromString(`{"title":"[draft] report","share":"\\\\server\\share","host.name":"a","tags":["x","y"],"empty":{}}`),
+	}
+
+	// cells and columns are not escaped
+	output, read := csvRoundTrip(t, rmaps)
+	assert.Equal(t, "host.name,share,tags,title\na,\\\\server\\share,[x y],[draft] report\n", output)
+	assert.Equal(t, map[string]interface{}{"host.name": "a", "share": `\\server\share`, "tags": "[x y]", "title": "[draft] report"}, read[0].Mapa)
+}
+
+func TestCSVIndexLikeColumns(t *testing.T) {
+	rmaps := []Rmap{MustNewFromString(`{"codes":{"200":"ok"},"items":["a"]}`)}
+
+	output, read := csvRoundTrip(t, rmaps, WithArrayMode(ArrayIndexed))
+	assert.Equal(t, "codes.\\200,items.0\nok,a\n", output)
+	assert.Equal(t, rmaps, read)
+}
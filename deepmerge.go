@@ -0,0 +1,268 @@
+package rmap
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ArrayStrategy defines how DeepMerge merges two arrays
+type ArrayStrategy struct {
+	mode arrayMode
+	key  string
+}
+
+type arrayMode int
+
+const (
+	arrayReplace arrayMode = iota
+	arrayAppend
+	arrayUniqueUnion
+	arrayMergeByKey
+)
+
+var (
+	// ArrayReplace replaces destination array by source array (default)
+	ArrayReplace = ArrayStrategy{mode: arrayReplace}
+	// ArrayAppend appends members of source array to destination array
+	ArrayAppend = ArrayStrategy{mode: arrayAppend}
+	// ArrayUniqueUnion appends members of source array not present in destination array
+	ArrayUniqueUnion = ArrayStrategy{mode: arrayUniqueUnion}
+)
+
+// ArrayMergeByKey merges objects in arrays with equal value of key recursively, other source members are appended
+func ArrayMergeByKey(key string) ArrayStrategy {
+	return ArrayStrategy{mode: arrayMergeByKey, key: key}
+}
+
+// NullHandling defines what DeepMerge does with null in source
+type NullHandling int
+
+const (
+	// NullSets sets null in destination (default)
+	NullSets NullHandling = iota
+	// NullDeletes deletes key from destination, like JSON Merge Patch
+	NullDeletes
+	// NullIgnored keeps destination value
+	NullIgnored
+)
+
+// DeepMergeOption configures DeepMerge
+type DeepMergeOption func(*deepMergeOptions)
+
+type deepMergeOptions struct {
+	arrays              ArrayStrategy
+	nulls               NullHandling
+	rejectTypeConflicts bool
+	overrides           []deepMergeOverride
+}
+
+type deepMergeOverride struct {
+	jptr string
+	ptr  Pointer
+	opts []DeepMergeOption
+}
+
+// WithArrayStrategy sets how arrays are merged
+func WithArrayStrategy(strategy ArrayStrategy) DeepMergeOption {
+	return func(o *deepMergeOptions) {
+		o.arrays = strategy
+	}
+}
+
+// WithNullHandling sets what happens with null values in source
+func WithNullHandling(handling NullHandling) DeepMergeOption {
+	return func(o *deepMergeOptions) {
+		o.nulls = handling
+	}
+}
+
+// RejectTypeConflicts makes DeepMerge fail, if source value has different JSON type than existing non-null destination value
+func RejectTypeConflicts() DeepMergeOption {
+	return func(o *deepMergeOptions) {
+		o.rejectTypeConflicts = true
+	}
+}
+
+// WithPointerOptions applies opts to value on JSONPointer jptr and everything below it
+// Token * in jptr matches any key or array index, more specific overrides are applied later, invalid jptr is returned as error by DeepMerge
+func WithPointerOptions(jptr string, opts ...DeepMergeOption) DeepMergeOption {
+	return func(o *deepMergeOptions) {
+		o.overrides = append(o.overrides, deepMergeOverride{jptr: jptr, opts: opts})
+	}
+}
+
+// DeepMerge merges src into this Rmap recursively, objects are merged key by key, arrays according to ArrayStrategy
+// Rmap is modified in-place and only if merge succeeds, values from src are copied
+func (r Rmap) DeepMerge(src Rmap, opts ...DeepMergeOption) error {
+	options := deepMergeOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	for index, override := range options.overrides {
+		ptr, err := ParsePointer(override.jptr)
+		if err != nil {
+			return errors.Wrapf(err, "WithPointerOptions() has invalid JSONPointer")
+		}
+		options.overrides[index].ptr = ptr
+	}
+
+	merged, _, err := options.merge(r, Pointer{}, r.Mapa, true, src.Mapa)
+	if err != nil {
+		return err
+	}
+
+	obj, _ := asObject(merged)
+	return r.replaceWith(NewFromMap(obj))
+}
+
+// at returns options with overrides matching ptr applied
+func (o deepMergeOptions) at(ptr Pointer) deepMergeOptions {
+	effective := o
+	for length := 0; length <= len(ptr); length++ {
+		for _, override := range o.overrides {
+			if len(override.ptr) == length && override.matches(ptr) {
+				for _, opt := range override.opts {
+					opt(&effective)
+				}
+			}
+		}
+	}
+
+	return effective
+}
+
+func (o deepMergeOverride) matches(ptr Pointer) bool {
+	if len(o.ptr) > len(ptr) {
+		return false
+	}
+
+	for index, token := range o.ptr {
+		if token != "*" && token != ptr[index] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// merge returns merged value and false, if value should be deleted
+func (o deepMergeOptions) merge(r Rmap, ptr Pointer, dst interface{}, dstExists bool, src interface{}) (interface{}, bool, error) {
+	options := o.at(ptr)
+
+	if src == nil {
+		switch options.nulls {
+		case NullDeletes:
+			return nil, false, nil
+		case NullIgnored:
+			return dst, dstExists, nil
+		default:
+			return nil, true, nil
+		}
+	}
+
+	if !dstExists || dst == nil {
+		if _, srcIsObj := asObject(src); !srcIsObj {
+			return DeepCopy(src), true, nil
+		}
+
+		// new object is merged into empty one, so null handling and overrides apply inside it
+		dst = map[string]interface{}{}
+	}
+
+	dstObj, dstIsObj := asObject(dst)
+	srcObj, srcIsObj := asObject(src)
+	if dstIsObj && srcIsObj {
+		out := make(map[string]interface{}, len(dstObj)+len(srcObj))
+		for key, value := range dstObj {
+			out[key] = value
+		}
+
+		for key, srcValue := range srcObj {
+			dstValue, exists := out[key]
+			merged, keep, err := o.merge(r, ptr.Append(key), dstValue, exists, srcValue)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if keep {
+				out[key] = merged
+			} else {
+				delete(out, key)
+			}
+		}
+		return out, true, nil
+	}
+
+	dstArr, dstIsArr := asArray(dst)
+	srcArr, srcIsArr := asArray(src)
+	if dstIsArr && srcIsArr {
+		merged, err := o.mergeArrays(r, ptr, options.arrays, dstArr, srcArr)
+		return merged, true, err
+	}
+
+	if options.rejectTypeConflicts && jsonTypeOf(dst) != jsonTypeOf(src) {
+		return nil, false, errors.Wrapf(newTypeMismatchError(r, ptr.String(), -1, strings.ToUpper(jsonTypeOf(dst)), src), "type conflict")
+	}
+
+	return DeepCopy(src), true, nil
+}
+
+func (o deepMergeOptions) mergeArrays(r Rmap, ptr Pointer, strategy ArrayStrategy, dst, src []interface{}) ([]interface{}, error) {
+	switch strategy.mode {
+	case arrayAppend:
+		out := append([]interface{}{}, dst...)
+		for _, elem := range src {
+			out = append(out, DeepCopy(elem))
+		}
+		return out, nil
+	case arrayUniqueUnion:
+		return unionArrays(dst, src), nil
+	case arrayMergeByKey:
+		out := append([]interface{}{}, dst...)
+		for _, srcElem := range src {
+			index := indexByKey(out, strategy.key, srcElem)
+			if index < 0 {
+				out = append(out, DeepCopy(srcElem))
+				continue
+			}
+
+			merged, keep, err := o.merge(r, ptr.Append(strconv.Itoa(index)), out[index], true, srcElem)
+			if err != nil {
+				return nil, err
+			}
+
+			if keep {
+				out[index] = merged
+			}
+		}
+		return out, nil
+	default:
+		return DeepCopy(src).([]interface{}), nil
+	}
+}
+
+// indexByKey returns index of object in arr, which has the same value of key as value, or -1
+func indexByKey(arr []interface{}, key string, value interface{}) int {
+	valueObj, ok := asObject(value)
+	if !ok {
+		return -1
+	}
+
+	keyValue, exists := valueObj[key]
+	if !exists {
+		return -1
+	}
+
+	for index, elem := range arr {
+		if elemObj, ok := asObject(elem); ok {
+			if elemValue, exists := elemObj[key]; exists && jsonEqual(elemValue, keyValue) {
+				return index
+			}
+		}
+	}
+
+	return -1
+}
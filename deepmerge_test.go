@@ -0,0 +1,93 @@
+package rmap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeepMerge(t *testing.T) {
+	dst := MustNewFromString(`{"name":"base","nested":{"a":1,"b":{"c":2}},"tags":["a","b"],"gone":1}`)
+	src := MustNewFromString(`{"nested":{"b":{"d":3}},"tags":["b","c"],"gone":null,"new":true}`)
+
+	assert.Nil(t, dst.DeepMerge(src))
+	assert.Equal(t, `{"gone":null,"name":"base","nested":{"a":1,"b":{"c":2,"d":3}},"new":true,"tags":["b","c"]}`, dst.String())
+
+	// source is not modified nor shared
+	assert.Nil(t, dst.SetJPtr("/tags/0", "x"))
+	assert.Equal(t, "b", src.MustGetJPtrString("/tags/0"))
+}
+
+func TestDeepMergeOptions(t *testing.T) {
+	src := MustNewFromString(`{"tags":["b","c"],"gone":null,"missing":null}`)
+
+	dst := MustNewFromString(`{"tags":["a","b"],"gone":1}`)
+	assert.Nil(t, dst.DeepMerge(src, WithArrayStrategy(ArrayAppend), WithNullHandling(NullDeletes)))
+	assert.Equal(t, `{"tags":["a","b","b","c"]}`, dst.String())
+
+	dst = MustNewFromString(`{"tags":["a","b"],"gone":1}`)
+	assert.Nil(t, dst.DeepMerge(src, WithArrayStrategy(ArrayUniqueUnion), WithNullHandling(NullIgnored)))
+	assert.Equal(t, `{"gone":1,"tags":["a","b","c"]}`, dst.String())
+}
+
+func TestDeepMergeByKeyWithOverrides(t *testing.T) {
+	dst := MustNewFromString(`{"containers":[{"name":"app","image":"app:1","env":[{"name":"A","value":"1"}]},{"name":"sidecar","image":"proxy:1"}],"args":["x"]}`)
+	src := MustNewFromString(`{"containers":[{"name":"app","image":"app:2","env":[{"name":"B","value":"2"}]},{"name":"new","image":"new:1"}],"args":["y"]}`)
+
+	err := dst.DeepMerge(src,
+		WithPointerOptions("/containers", WithArrayStrategy(ArrayMergeByKey("name"))),
+		WithPointerOptions("/containers/*/env", WithArrayStrategy(ArrayAppend)),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"args":["y"],"containers":[{"env":[{"name":"A","value":"1"},{"name":"B","value":"2"}],"image":"app:2","name":"app"},{"image":"proxy:1","name":"sidecar"},{"image":"new:1","name":"new"}]}`, dst.String())
+}
+
+func TestDeepMergeTypeConflict(t *testing.T) {
+	dst := MustNewFromString(`{"nested":{"a":{"b":1}}}`)
+	original := dst.String()
+
+	err := dst.DeepMerge(MustNewFromString(`{"nested":{"a":"string"}}`), RejectTypeConflicts())
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+	var mismatch *TypeMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "/nested/a", mismatch.Path)
+	assert.Equal(t, original, dst.String())
+
+	assert.Nil(t, dst.DeepMerge(MustNewFromString(`{"nested":{"a":"string"}}`)))
+	assert.Equal(t, "string", dst.MustGetJPtrString("/nested/a"))
+}
+
+func TestDeepMergeNewSubtree(t *testing.T) {
+	src := MustNewFromString(`{"a":{"b":null,"c":{"d":null,"e":1}},"list":[{"x":null}]}`)
+
+	dst := NewEmpty()
+	assert.Nil(t, dst.DeepMerge(src, WithNullHandling(NullDeletes)))
+	assert.Equal(t, `{"a":{"c":{"e":1}},"list":[{"x":null}]}`, dst.String())
+
+	dst = MustNewFromString(`{"a":null}`)
+	assert.Nil(t, dst.DeepMerge(src, WithNullHandling(NullIgnored), WithPointerOptions("/a/c", WithNullHandling(NullSets))))
+	assert.Equal(t, `{"a":{"c":{"d":null,"e":1}},"list":[{"x":null}]}`, dst.String())
+
+	// source is not shared with new subtree
+	assert.Nil(t, dst.SetJPtr("/a/c/e", 2))
+	assert.Equal(t, 1, src.MustGetJPtrInt("/a/c/e"))
+}
+
+func TestDeepMergeInvalidPointer(t *testing.T) {
+	dst := MustNewFromString(`{"a":1}`)
+
+	err := dst.DeepMerge(MustNewFromString(`{"a":2}`), WithPointerOptions("a", WithArrayStrategy(ArrayAppend)))
+	assert.True(t, errors.Is(err, ErrInvalidPointer))
+	assert.Equal(t, `{"a":1}`, dst.String())
+}
+
+func TestDeepMergeNilMapa(t *testing.T) {
+	err := Rmap{}.DeepMerge(MustNewFromString(`{"a":1}`))
+	assert.True(t, errors.Is(err, ErrNilMapa))
+
+	// nil source is empty object
+	dst := MustNewFromString(`{"a":1}`)
+	assert.Nil(t, dst.DeepMerge(Rmap{}))
+	assert.Equal(t, `{"a":1}`, dst.String())
+}
@@ -0,0 +1,76 @@
+package rmap
+
+// ApplySchemaDefaults sets missing properties to default values from schema
+// Defaults are applied recursively in nested objects, array items and allOf subschemas, existing values (including null) are kept
+// External references are resolved by DefaultSchemaRegistry, Rmap is modified in-place
+func (r Rmap) ApplySchemaDefaults(schema Rmap) error {
+	root, err := DefaultSchemaRegistry.bundle(schema, "")
+	if err != nil {
+		return err
+	}
+
+	return applyDefaults(r, root)
+}
+
+// ApplyDefaults is ApplySchemaDefaults with compiled schema
+func (v *Validator) ApplyDefaults(r Rmap) error {
+	return applyDefaults(r, v.schema.Mapa)
+}
+
+func applyDefaults(r Rmap, root map[string]interface{}) error {
+	result, changed := applySchema(root, root, r.Mapa, func(value interface{}, schema map[string]interface{}) (interface{}, bool) {
+		return schemaDefaults(root, value, schema)
+	})
+
+	if changed {
+		obj, _ := asObject(result)
+		return r.replaceWith(NewFromMap(obj))
+	}
+
+	return nil
+}
+
+// schemaDefaults returns copy of object value with missing properties set to their defaults
+func schemaDefaults(root map[string]interface{}, value interface{}, schema map[string]interface{}) (interface{}, bool) {
+	obj, isObj := asObject(value)
+	props, hasProps := asObject(schema["properties"])
+	if !isObj || !hasProps {
+		return value, false
+	}
+
+	locator := schemaLocator{root: root}
+	var out map[string]interface{}
+	for name, propI := range props {
+		if _, exists := obj[name]; exists {
+			continue
+		}
+
+		prop, ok := asObject(propI)
+		if !ok {
+			continue
+		}
+
+		// default can be next to $ref or in referenced schema
+		def, hasDefault := prop["default"]
+		if !hasDefault {
+			if _, resolved, ok := locator.resolve(Pointer{}, prop); ok {
+				def, hasDefault = resolved["default"]
+			}
+		}
+
+		if !hasDefault {
+			continue
+		}
+
+		if out == nil {
+			out = shallowCopyObject(obj)
+		}
+		out[name] = DeepCopy(def)
+	}
+
+	if out == nil {
+		return value, false
+	}
+
+	return out, true
+}
@@ -0,0 +1,62 @@
+package rmap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySchemaDefaults(t *testing.T) {
+	schema := MustNewFromString(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"enabled": {"type": "boolean", "default": true},
+			"limits": {"type": "object", "default": {}, "properties": {"cpu": {"default": 1}, "memory": {"$ref": "#/definitions/memory"}}},
+			"ports": {"type": "array", "items": {"type": "object", "properties": {"protocol": {"default": "TCP"}}}}
+		},
+		"allOf": [{"properties": {"labels": {"default": {"app": "x"}}}}],
+		"definitions": {"memory": {"type": "string", "default": "1Gi"}}
+	}`)
+
+	rm := MustNewFromString(`{"name":"foo","enabled":false,"ports":[{"port":80},{"port":53,"protocol":"UDP"}]}`)
+	assert.Nil(t, rm.ApplySchemaDefaults(schema))
+	assert.Equal(t, `{"enabled":false,"labels":{"app":"x"},"limits":{"cpu":1,"memory":"1Gi"},"name":"foo","ports":[{"port":80,"protocol":"TCP"},{"port":53,"protocol":"UDP"}]}`, rm.String())
+
+	// explicit null is kept
+	rm = MustNewFromString(`{"limits":null}`)
+	assert.Nil(t, rm.ApplySchemaDefaults(schema))
+	assert.Nil(t, rm.Mapa["limits"])
+
+	// defaults are copied, not shared
+	validator, err := CompileSchema(schema)
+	assert.Nil(t, err)
+
+	first, second := NewEmpty(), NewEmpty()
+	assert.Nil(t, validator.ApplyDefaults(first))
+	assert.Nil(t, validator.ApplyDefaults(second))
+	first.MustSetJPtr("/labels/app", "changed")
+	assert.Equal(t, "x", second.MustGetJPtrString("/labels/app"))
+	assert.Equal(t, "1Gi", second.MustGetJPtrString("/limits/memory"))
+
+	// typed values, which are not changed, are kept
+	rm = NewFromMap(map[string]interface{}{"enabled": true, "limits": map[string]interface{}{"cpu": 2, "memory": "2Gi"}, "labels": map[string]string{}, "tags": []string{"a"}})
+	assert.Nil(t, rm.ApplySchemaDefaults(schema))
+	assert.Equal(t, []string{"a"}, rm.Mapa["tags"])
+	assert.Equal(t, map[string]string{}, rm.Mapa["labels"])
+
+	assert.NotNil(t, rm.ApplySchemaDefaults(MustNewFromString(`{"$ref":"missing.json"}`)))
+}
+
+func TestApplySchemaDefaultsNilMapa(t *testing.T) {
+	schema := MustNewFromString(`{"properties":{"a":{"default":1}}}`)
+
+	err := Rmap{}.ApplySchemaDefaults(schema)
+	assert.True(t, errors.Is(err, ErrNilMapa))
+
+	validator, err := CompileSchema(schema)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(validator.ApplyDefaults(Rmap{}), ErrNilMapa))
+	assert.Nil(t, validator.Coerce(Rmap{}))
+}
@@ -0,0 +1,208 @@
+package rmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is kind of Change found by Diff
+type ChangeKind string
+
+const (
+	ChangeAdded       ChangeKind = "added"
+	ChangeRemoved     ChangeKind = "removed"
+	ChangeModified    ChangeKind = "modified"
+	ChangeTypeChanged ChangeKind = "type-changed" // JSON type of value changed, for example string to number
+)
+
+// Change is one difference between two documents
+type Change struct {
+	Pointer string
+	Kind    ChangeKind
+	Old     interface{} // not set for ChangeAdded
+	New     interface{} // not set for ChangeRemoved
+}
+
+// MarshalJSON omits "old" for added and "new" for removed values, null values are kept otherwise
+func (c Change) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{
+		"pointer": c.Pointer,
+		"kind":    c.Kind,
+	}
+
+	if c.Kind != ChangeAdded {
+		out["old"] = c.Old
+	}
+
+	if c.Kind != ChangeRemoved {
+		out["new"] = c.New
+	}
+
+	return json.Marshal(out)
+}
+
+// Changes is result of Diff, sorted by JSONPointer (array members by index)
+type Changes []Change
+
+// DiffOption configures Diff
+type DiffOption func(*diffOptions)
+
+type diffOptions struct {
+	numericTypeInsensitive bool
+}
+
+// NumericTypeInsensitive compares numbers by exact decimal value only, so int 1, float64 1.0 and json.Number "1" are equal
+// By default, numbers with different Go type are reported as modified
+func NumericTypeInsensitive() DiffOption {
+	return func(o *diffOptions) {
+		o.numericTypeInsensitive = true
+	}
+}
+
+// Diff returns structural differences between this Rmap and other, Rmap and map[string]interface{} (or any slices) are considered same
+func (r Rmap) Diff(other Rmap, opts ...DiffOption) Changes {
+	options := diffOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	changes := Changes{}
+	options.diff(Pointer{}, r.Mapa, other.Mapa, &changes)
+	return changes
+}
+
+func (o diffOptions) diff(ptr Pointer, from, to interface{}, changes *Changes) {
+	fromObj, fromIsObj := asObject(from)
+	toObj, toIsObj := asObject(to)
+	if fromIsObj && toIsObj {
+		keys := make([]string, 0, len(fromObj)+len(toObj))
+		for key := range fromObj {
+			keys = append(keys, key)
+		}
+		for key := range toObj {
+			if _, exists := fromObj[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fromValue, fromExists := fromObj[key]
+			toValue, toExists := toObj[key]
+			switch {
+			case !fromExists:
+				*changes = append(*changes, Change{Pointer: ptr.Append(key).String(), Kind: ChangeAdded, New: toValue})
+			case !toExists:
+				*changes = append(*changes, Change{Pointer: ptr.Append(key).String(), Kind: ChangeRemoved, Old: fromValue})
+			default:
+				o.diff(ptr.Append(key), fromValue, toValue, changes)
+			}
+		}
+		return
+	}
+
+	fromArr, fromIsArr := asArray(from)
+	toArr, toIsArr := asArray(to)
+	if fromIsArr && toIsArr {
+		for index := 0; index < len(fromArr) || index < len(toArr); index++ {
+			elemPtr := ptr.Append(strconv.Itoa(index))
+			switch {
+			case index >= len(fromArr):
+				*changes = append(*changes, Change{Pointer: elemPtr.String(), Kind: ChangeAdded, New: toArr[index]})
+			case index >= len(toArr):
+				*changes = append(*changes, Change{Pointer: elemPtr.String(), Kind: ChangeRemoved, Old: fromArr[index]})
+			default:
+				o.diff(elemPtr, fromArr[index], toArr[index], changes)
+			}
+		}
+		return
+	}
+
+	if jsonTypeOf(from) != jsonTypeOf(to) {
+		*changes = append(*changes, Change{Pointer: ptr.String(), Kind: ChangeTypeChanged, Old: from, New: to})
+		return
+	}
+
+	if !o.equalScalars(from, to) {
+		*changes = append(*changes, Change{Pointer: ptr.String(), Kind: ChangeModified, Old: from, New: to})
+	}
+}
+
+func (o diffOptions) equalScalars(a, b interface{}) bool {
+	if o.numericTypeInsensitive {
+		// numbers are compared exactly, float64 cannot distinguish large integers
+		if numA, ok := asDecimal(a); ok {
+			numB, ok := asDecimal(b)
+			return ok && numA.Equal(numB)
+		}
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// jsonTypeOf returns name of JSON type of value, or Go type for values without JSON equivalent
+func jsonTypeOf(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+
+	if _, ok := asObject(value); ok {
+		return "object"
+	}
+
+	if _, ok := asArray(value); ok {
+		return "array"
+	}
+
+	if _, ok := asNumber(value); ok {
+		return "number"
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func (c Changes) Bytes() []byte {
+	byt, _ := json.Marshal(c)
+	return byt
+}
+
+func (c Changes) String() string {
+	return string(c.Bytes())
+}
+
+// Unified renders Changes as text in unified diff style, one or two lines per change:
+// "- /pointer: old" for removed and "+ /pointer: new" for added, modified values have both lines
+func (c Changes) Unified() string {
+	var sb strings.Builder
+	for _, change := range c {
+		if change.Kind != ChangeAdded {
+			fmt.Fprintf(&sb, "- %s: %s\n", change.Pointer, renderDiffValue(change.Old))
+		}
+
+		if change.Kind != ChangeRemoved {
+			fmt.Fprintf(&sb, "+ %s: %s\n", change.Pointer, renderDiffValue(change.New))
+		}
+	}
+
+	return sb.String()
+}
+
+func renderDiffValue(value interface{}) string {
+	byt, err := CanonicalJSON(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(byt)
+}
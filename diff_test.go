@@ -0,0 +1,48 @@
+package rmap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	from := MustNewFromString(`{"name":"foo","tags":["a","b"],"count":1,"nested":{"flag":true,"gone":null},"id":"1"}`)
+	to := MustNewFromString(`{"name":"bar","tags":["a","c","d"],"count":1,"nested":{"flag":true,"new":{"x":1}},"id":1}`)
+
+	changes := from.Diff(to)
+	assert.Equal(t, Changes{
+		{Pointer: "/id", Kind: ChangeTypeChanged, Old: "1", New: 1.0},
+		{Pointer: "/name", Kind: ChangeModified, Old: "foo", New: "bar"},
+		{Pointer: "/nested/gone", Kind: ChangeRemoved, Old: nil},
+		{Pointer: "/nested/new", Kind: ChangeAdded, New: map[string]interface{}{"x": 1.0}},
+		{Pointer: "/tags/1", Kind: ChangeModified, Old: "b", New: "c"},
+		{Pointer: "/tags/2", Kind: ChangeAdded, New: "d"},
+	}, changes)
+
+	assert.Equal(t, "- /id: \"1\"\n+ /id: 1\n- /name: \"foo\"\n+ /name: \"bar\"\n- /nested/gone: null\n+ /nested/new: {\"x\":1}\n- /tags/1: \"b\"\n+ /tags/1: \"c\"\n+ /tags/2: \"d\"\n", changes.Unified())
+	assert.Equal(t, `[{"kind":"type-changed","new":1,"old":"1","pointer":"/id"},{"kind":"modified","new":"bar","old":"foo","pointer":"/name"},{"kind":"removed","old":null,"pointer":"/nested/gone"},{"kind":"added","new":{"x":1},"pointer":"/nested/new"},{"kind":"modified","new":"c","old":"b","pointer":"/tags/1"},{"kind":"added","new":"d","pointer":"/tags/2"}]`, changes.String())
+
+	assert.Len(t, from.Diff(from.Copy()), 0)
+}
+
+func TestDiffNumericTypeInsensitive(t *testing.T) {
+	from := NewFromMap(map[string]interface{}{"int": 1, "rmap": NewFromMap(map[string]interface{}{"a": int64(2)})})
+	to := MustNewFromBytesWithOptions([]byte(`{"int":1.0,"rmap":{"a":2}}`), UseNumber())
+
+	assert.Len(t, from.Diff(to), 2)
+	assert.Equal(t, ChangeModified, from.Diff(to)[0].Kind)
+	assert.Len(t, from.Diff(to, NumericTypeInsensitive()), 0)
+
+	// large numbers are compared exactly
+	from = MustNewFromBytesWithOptions([]byte(`{"a":9007199254740993,"b":18446744073709551615}`), UseNumber())
+	to = NewFromMap(map[string]interface{}{"a": json.Number("9007199254740992"), "b": uint64(18446744073709551614)})
+	changes := from.Diff(to, NumericTypeInsensitive())
+	assert.Len(t, changes, 2)
+	assert.Equal(t, "/a", changes[0].Pointer)
+	assert.Equal(t, ChangeModified, changes[0].Kind)
+	assert.Equal(t, "/b", changes[1].Pointer)
+
+	assert.Len(t, to.Diff(NewFromMap(map[string]interface{}{"a": int64(9007199254740992), "b": uint64(18446744073709551614)}), NumericTypeInsensitive()), 0)
+}
@@ -0,0 +1,192 @@
+package rmap
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Algorithms of encrypted values, names are taken from JWE
+const (
+	EncryptionXChaCha20Poly1305 = "XC20P"
+	EncryptionAES256GCM         = "A256GCM"
+)
+
+// EncryptionKey is symmetric key used by EncryptJPtr and DecryptJPtr
+type EncryptionKey struct {
+	ID        string // recorded in envelope, DecryptJPtr refuses envelope with different ID
+	Algorithm string // EncryptionXChaCha20Poly1305 (default) or EncryptionAES256GCM
+	Key       []byte // 32 bytes
+}
+
+// EncryptedEnvelope replaces encrypted value in document
+// Ciphertext is authenticated together with JSONPointer of value, so envelope cannot be moved to other location
+type EncryptedEnvelope struct {
+	Algorithm  string `json:"alg"`
+	KeyID      string `json:"kid"`
+	Nonce      string `json:"nonce"`      // base64url without padding
+	Ciphertext string `json:"ciphertext"` // base64url without padding, JSON of original value
+}
+
+// EncryptJPtr replaces values on JSONPointers with EncryptedEnvelope objects, rest of document is untouched
+// Rmap is modified only if all values are encrypted successfully
+func (r Rmap) EncryptJPtr(ptrs []string, key EncryptionKey) error {
+	algorithm := key.Algorithm
+	if algorithm == "" {
+		algorithm = EncryptionXChaCha20Poly1305
+	}
+
+	aead, err := newAEAD(algorithm, key.Key)
+	if err != nil {
+		return err
+	}
+
+	doc := r.Copy()
+	for _, jptr := range ptrs {
+		value, err := doc.GetJPtr(jptr)
+		if err != nil {
+			return errors.Wrapf(err, "doc.GetJPtr() failed")
+		}
+
+		plaintext, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrapf(err, "json.Marshal() failed")
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return errors.Wrapf(err, "rand.Read() failed")
+		}
+
+		envelope := EncryptedEnvelope{
+			Algorithm:  algorithm,
+			KeyID:      key.ID,
+			Nonce:      base64.RawURLEncoding.EncodeToString(nonce),
+			Ciphertext: base64.RawURLEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, []byte(jptr))),
+		}
+
+		if err := doc.SetJPtr(jptr, envelope.toMap()); err != nil {
+			return errors.Wrapf(err, "doc.SetJPtr() failed")
+		}
+	}
+
+	return r.replaceWith(doc)
+}
+
+// DecryptJPtr replaces EncryptedEnvelope objects on JSONPointers with original values
+// Pointers are processed in reverse order, so the same list as in EncryptJPtr can be used, even if pointers are nested
+// Rmap is modified only if all values are decrypted successfully
+func (r Rmap) DecryptJPtr(ptrs []string, key EncryptionKey) error {
+	doc := r.Copy()
+	for index := len(ptrs) - 1; index >= 0; index-- {
+		jptr := ptrs[index]
+
+		envelope, err := doc.getEnvelope(jptr)
+		if err != nil {
+			return err
+		}
+
+		if envelope.KeyID != key.ID {
+			return errors.Wrapf(ErrDecryption, "JSONPointer: %s is encrypted by key: %s, not by: %s", jptr, envelope.KeyID, key.ID)
+		}
+
+		aead, err := newAEAD(envelope.Algorithm, key.Key)
+		if err != nil {
+			return err
+		}
+
+		nonce, err := base64.RawURLEncoding.DecodeString(envelope.Nonce)
+		if err != nil || len(nonce) != aead.NonceSize() {
+			return errors.Wrapf(ErrDecryption, "JSONPointer: %s has invalid nonce", jptr)
+		}
+
+		ciphertext, err := base64.RawURLEncoding.DecodeString(envelope.Ciphertext)
+		if err != nil {
+			return errors.Wrapf(ErrDecryption, "JSONPointer: %s has invalid ciphertext", jptr)
+		}
+
+		plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(jptr))
+		if err != nil {
+			return errors.Wrapf(ErrDecryption, "JSONPointer: %s: %v", jptr, err)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(plaintext, &value); err != nil {
+			return errors.Wrapf(err, "json.Unmarshal() failed")
+		}
+
+		if err := doc.SetJPtr(jptr, value); err != nil {
+			return errors.Wrapf(err, "doc.SetJPtr() failed")
+		}
+	}
+
+	return r.replaceWith(doc)
+}
+
+// IsEncryptedJPtr returns true, if value on JSONPointer is EncryptedEnvelope
+func (r Rmap) IsEncryptedJPtr(jptr string) bool {
+	_, err := r.getEnvelope(jptr)
+	return err == nil
+}
+
+func (r Rmap) getEnvelope(jptr string) (EncryptedEnvelope, error) {
+	valI, err := r.GetJPtr(jptr)
+	if err != nil {
+		return EncryptedEnvelope{}, errors.Wrapf(err, "r.GetJPtr() failed")
+	}
+
+	obj, ok := asObject(valI)
+	if !ok {
+		return EncryptedEnvelope{}, newTypeMismatchError(r, jptr, -1, "ENCRYPTED ENVELOPE", valI)
+	}
+
+	envelope := EncryptedEnvelope{}
+	fields := map[string]*string{"alg": &envelope.Algorithm, "kid": &envelope.KeyID, "nonce": &envelope.Nonce, "ciphertext": &envelope.Ciphertext}
+	if len(obj) != len(fields) {
+		return EncryptedEnvelope{}, newTypeMismatchError(r, jptr, -1, "ENCRYPTED ENVELOPE", valI)
+	}
+
+	for name, field := range fields {
+		value, ok := obj[name].(string)
+		if !ok {
+			return EncryptedEnvelope{}, newTypeMismatchError(r, jptr, -1, "ENCRYPTED ENVELOPE", valI)
+		}
+		*field = value
+	}
+
+	return envelope, nil
+}
+
+func (e EncryptedEnvelope) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"alg":        e.Algorithm,
+		"kid":        e.KeyID,
+		"nonce":      e.Nonce,
+		"ciphertext": e.Ciphertext,
+	}
+}
+
+func newAEAD(algorithm string, key []byte) (cipher.AEAD, error) {
+	switch algorithm {
+	case EncryptionXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	case EncryptionAES256GCM:
+		if len(key) != 32 {
+			return nil, errors.Errorf("invalid AES-256 key length: %d", len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "aes.NewCipher() failed")
+		}
+
+		return cipher.NewGCM(block)
+	default:
+		return nil, errors.Errorf("unsupported encryption algorithm: %s", algorithm)
+	}
+}
@@ -0,0 +1,70 @@
+package rmap
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecryptJPtr(t *testing.T) {
+	for _, algorithm := range []string{"", EncryptionXChaCha20Poly1305, EncryptionAES256GCM} {
+		key := EncryptionKey{ID: "key-1", Algorithm: algorithm, Key: bytes.Repeat([]byte{1}, 32)}
+		rm := MustNewFromString(`{"name":"John","ssn":"123-45-6789","contact":{"emails":["a@b.c"],"phone":null}}`)
+		original := rm.Copy()
+		ptrs := []string{"/ssn", "/contact/emails/0", "/contact"}
+
+		assert.Nil(t, rm.EncryptJPtr(ptrs, key))
+		assert.Equal(t, "John", rm.MustGetString("name"))
+		assert.True(t, rm.IsEncryptedJPtr("/ssn"))
+		assert.True(t, rm.IsEncryptedJPtr("/contact"))
+		assert.False(t, rm.IsEncryptedJPtr("/name"))
+		assert.NotContains(t, rm.String(), "123-45-6789")
+		assert.NotContains(t, rm.String(), "a@b.c")
+
+		envelope := rm.MustGetRmap("ssn")
+		assert.Equal(t, "key-1", envelope.MustGetString("kid"))
+		if algorithm == "" {
+			assert.Equal(t, EncryptionXChaCha20Poly1305, envelope.MustGetString("alg"))
+		}
+
+		assert.Nil(t, rm.ValidateSchema(MustNewFromString(`{"type":"object","properties":{"name":{"type":"string"}},"required":["name","ssn"]}`)))
+
+		assert.Nil(t, rm.DecryptJPtr(ptrs, key))
+		assert.Equal(t, original.String(), rm.String())
+	}
+}
+
+func TestDecryptJPtrErrors(t *testing.T) {
+	key := EncryptionKey{ID: "key-1", Key: bytes.Repeat([]byte{1}, 32)}
+	rm := MustNewFromString(`{"secret":"value","other":"value"}`)
+	assert.Nil(t, rm.EncryptJPtr([]string{"/secret"}, key))
+	encrypted := rm.String()
+
+	wrongKey := EncryptionKey{ID: "key-1", Key: bytes.Repeat([]byte{2}, 32)}
+	assert.True(t, errors.Is(rm.DecryptJPtr([]string{"/secret"}, wrongKey), ErrDecryption))
+
+	wrongID := EncryptionKey{ID: "key-2", Key: key.Key}
+	assert.True(t, errors.Is(rm.DecryptJPtr([]string{"/secret"}, wrongID), ErrDecryption))
+
+	// envelope moved to other location cannot be decrypted
+	moved := MustNewFromString(encrypted)
+	assert.Nil(t, moved.SetJPtr("/other", moved.MustGetRmap("secret").Mapa))
+	assert.True(t, errors.Is(moved.DecryptJPtr([]string{"/other"}, key), ErrDecryption))
+
+	err := rm.DecryptJPtr([]string{"/secret", "/other"}, key)
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+	// nothing is modified on error
+	assert.Equal(t, encrypted, rm.String())
+
+	assert.NotNil(t, rm.EncryptJPtr([]string{"/other"}, EncryptionKey{Key: []byte("short")}))
+	assert.True(t, errors.Is(rm.EncryptJPtr([]string{"/missing"}, key), ErrKeyNotFound))
+}
+
+func TestEncryptJPtrNilMapa(t *testing.T) {
+	key := EncryptionKey{ID: "key-1", Key: bytes.Repeat([]byte{1}, 32)}
+
+	assert.True(t, errors.Is(Rmap{}.EncryptJPtr(nil, key), ErrNilMapa))
+	assert.True(t, errors.Is(Rmap{}.DecryptJPtr(nil, key), ErrNilMapa))
+}
@@ -0,0 +1,202 @@
+package rmap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Sentinel errors, use errors.Is(err, rmap.ErrKeyNotFound) to check for category of error
+// Use errors.As() to get typed error with details
+var (
+	ErrKeyNotFound    = errors.New("key not found")
+	ErrTypeMismatch   = errors.New("type mismatch")
+	ErrInvalidPointer = errors.New("invalid JSONPointer")
+	ErrConversion     = errors.New("conversion failed")
+
+	// ErrInvalidSignature is returned by Rmap.Verify(), when JWS is malformed or does not match document
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrDecryption is returned by Rmap.DecryptJPtr(), when envelope cannot be authenticated by key
+	ErrDecryption = errors.New("decryption failed")
+
+	// ErrNilMapa is returned by methods modifying Rmap in-place, when Rmap is zero value without map (Rmap{})
+	ErrNilMapa = errors.New("Rmap has nil Mapa, it cannot be modified in-place")
+
+	// ErrSchemaValidation is matched by *ValidationError, when document does not satisfy JSON Schema
+	ErrSchemaValidation = errors.New("schema validation failed")
+
+	// ErrIndexOutOfRange is special case of ErrKeyNotFound, errors.Is() matches both
+	ErrIndexOutOfRange = errors.New("array index out of range")
+)
+
+// default maximum length of document snippet embedded in error messages
+const defaultErrorSnippetLength = 256
+
+// errorSnippetLength holds value set by SetErrorSnippetLength
+var errorSnippetLength atomic.Int64
+
+func init() {
+	errorSnippetLength.Store(defaultErrorSnippetLength)
+}
+
+// SetErrorSnippetLength sets maximum length of document snippet embedded in error messages (default is 256)
+// Longer documents are truncated, 0 disables snippets completely. It is safe to call concurrently with other functions
+func SetErrorSnippetLength(length int) {
+	errorSnippetLength.Store(int64(length))
+}
+
+// ErrorSnippetLength returns length set by SetErrorSnippetLength
+func ErrorSnippetLength() int {
+	return int(errorSnippetLength.Load())
+}
+
+// KeyNotFoundError is returned when key or JSONPointer does not exist
+type KeyNotFoundError struct {
+	Path    string // requested key or JSONPointer
+	Missing string // JSONPointer prefix of Path that does not exist, empty if not known
+	Snippet string // truncated document, can be empty
+}
+
+func (e *KeyNotFoundError) Error() string {
+	return describePath(e.Path) + " does not exist" + describeSnippet(e.Snippet)
+}
+
+func (e *KeyNotFoundError) Is(target error) bool {
+	return target == ErrKeyNotFound
+}
+
+// TypeMismatchError is returned when value exists, but has different type than requested
+type TypeMismatchError struct {
+	Path     string // key or JSONPointer
+	Index    int    // index in array, if value is array member, -1 otherwise
+	Expected string // name of expected type, for example STRING
+	Actual   string // Go type of actual value
+	Snippet  string // truncated document, can be empty
+}
+
+func (e *TypeMismatchError) Error() string {
+	msg := describePath(e.Path)
+	if e.Index >= 0 {
+		msg += fmt.Sprintf(", array index: %d", e.Index)
+	}
+
+	return msg + fmt.Sprintf(" is not of type: %s%s, but: %s", e.Expected, describeSnippet(e.Snippet), e.Actual)
+}
+
+func (e *TypeMismatchError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// IndexOutOfRangeError is returned when JSONPointer references array element that does not exist
+type IndexOutOfRangeError struct {
+	Pointer string // JSONPointer up to the array index
+	Index   int
+	Length  int    // length of referenced array
+	Snippet string // truncated document, can be empty
+}
+
+func (e *IndexOutOfRangeError) Error() string {
+	return fmt.Sprintf("JSONPointer: %s array index: %d is out of range, length is: %d%s", e.Pointer, e.Index, e.Length, describeSnippet(e.Snippet))
+}
+
+func (e *IndexOutOfRangeError) Is(target error) bool {
+	return target == ErrIndexOutOfRange || target == ErrKeyNotFound
+}
+
+// InvalidPointerError is returned when JSONPointer is malformed or cannot be resolved
+type InvalidPointerError struct {
+	Pointer string
+	Reason  string
+}
+
+func (e *InvalidPointerError) Error() string {
+	return fmt.Sprintf("JSONPointer: %s is invalid: %s", e.Pointer, e.Reason)
+}
+
+func (e *InvalidPointerError) Is(target error) bool {
+	return target == ErrInvalidPointer
+}
+
+// ConversionError is returned when value exists, but cannot be converted (parsed) to requested type
+type ConversionError struct {
+	Path   string // key or JSONPointer
+	Value  string // value that failed to convert
+	Target string // name of target type
+	Err    error  // cause, can be nil
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("%s (value: %s) cannot be converted to: %s", describePath(e.Path), e.Value, e.Target)
+}
+
+func (e *ConversionError) Is(target error) bool {
+	return target == ErrConversion
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+func newKeyNotFoundError(r Rmap, path string) error {
+	return &KeyNotFoundError{Path: path, Snippet: errorSnippet(r)}
+}
+
+func newTypeMismatchError(r Rmap, path string, index int, expected string, actual interface{}) error {
+	return &TypeMismatchError{
+		Path:     path,
+		Index:    index,
+		Expected: expected,
+		Actual:   fmt.Sprintf("%T", actual),
+		Snippet:  errorSnippet(r),
+	}
+}
+
+func newConversionError(path string, value interface{}, target string, err error) error {
+	return &ConversionError{Path: path, Value: redactErrorValue(path, value), Target: target, Err: err}
+}
+
+// describePath distinguishes JSONPointers from plain keys in error messages
+func describePath(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return "JSONPointer: " + path
+	}
+
+	return "key: " + path
+}
+
+func describeSnippet(snippet string) string {
+	if snippet == "" {
+		return ""
+	}
+
+	return " in object: " + snippet
+}
+
+// errorSnippet returns document redacted by error redaction policy and truncated to ErrorSnippetLength()
+func errorSnippet(r Rmap) string {
+	length := ErrorSnippetLength()
+	if length <= 0 {
+		return ""
+	}
+
+	// unredacted document is never serialized
+	if policy := loadErrorRedactionPolicy(); len(policy) > 0 {
+		r = r.redact(policy)
+	}
+
+	s := r.String()
+
+	if len(s) <= length {
+		return s
+	}
+
+	cut := length
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return s[:cut] + "..."
+}
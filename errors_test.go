@@ -0,0 +1,94 @@
+package rmap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyNotFoundError(t *testing.T) {
+	rm := MustNewFromString(`{"key":"value"}`)
+
+	_, err := rm.GetString("missing")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	var notFound *KeyNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "missing", notFound.Path)
+	assert.Equal(t, `{"key":"value"}`, notFound.Snippet)
+
+	_, err = rm.GetJPtr("/nested/missing")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	assert.Equal(t, `JSONPointer: /nested/missing does not exist in object: {"key":"value"}`, err.Error())
+}
+
+func TestTypeMismatchError(t *testing.T) {
+	rm := MustNewFromString(`{"key":"value","arr":["a",1]}`)
+
+	_, err := rm.GetInt("key")
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+
+	var mismatch *TypeMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "key", mismatch.Path)
+	assert.Equal(t, -1, mismatch.Index)
+	assert.Equal(t, "INT or FLOAT64", mismatch.Expected)
+	assert.Equal(t, "string", mismatch.Actual)
+
+	_, err = rm.GetIterableString("arr")
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, 1, mismatch.Index)
+	assert.Equal(t, "float64", mismatch.Actual)
+	assert.Equal(t, `key: arr, array index: 1 is not of type: STRING in object: {"arr":["a",1],"key":"value"}, but: float64`, err.Error())
+
+	_, err = rm.GetJPtrBool("/key")
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+	assert.Equal(t, `JSONPointer: /key is not of type: BOOLEAN in object: {"arr":["a",1],"key":"value"}, but: string`, err.Error())
+}
+
+func TestInvalidPointerError(t *testing.T) {
+	rm := MustNewFromString(`{"key":"value"}`)
+
+	_, err := rm.GetJPtr("key")
+	assert.True(t, errors.Is(err, ErrInvalidPointer))
+
+	var invalid *InvalidPointerError
+	assert.True(t, errors.As(err, &invalid))
+	assert.Equal(t, "key", invalid.Pointer)
+
+	_, err = rm.ExistsJPtr("key")
+	assert.True(t, errors.Is(err, ErrInvalidPointer))
+}
+
+func TestConversionError(t *testing.T) {
+	rm := MustNewFromString(`{"time":"yesterday","amount":"1.2.3"}`)
+
+	_, err := rm.GetTime("time")
+	assert.True(t, errors.Is(err, ErrConversion))
+
+	var conversion *ConversionError
+	assert.True(t, errors.As(err, &conversion))
+	assert.Equal(t, "time", conversion.Path)
+	assert.Equal(t, "yesterday", conversion.Value)
+	assert.NotNil(t, errors.Unwrap(conversion))
+
+	_, err = rm.GetJPtrDecimal("/amount")
+	assert.True(t, errors.As(err, &conversion))
+	assert.Equal(t, "decimal", conversion.Target)
+}
+
+func TestErrorSnippetLength(t *testing.T) {
+	defer SetErrorSnippetLength(ErrorSnippetLength())
+
+	rm := NewFromMap(map[string]interface{}{"key": strings.Repeat("x", 100)})
+
+	SetErrorSnippetLength(10)
+	_, err := rm.GetInt("key")
+	assert.Equal(t, `key: key is not of type: INT or FLOAT64 in object: {"key":"xx..., but: string`, err.Error())
+
+	SetErrorSnippetLength(0)
+	_, err = rm.GetInt("key")
+	assert.Equal(t, `key: key is not of type: INT or FLOAT64, but: string`, err.Error())
+}
@@ -0,0 +1,307 @@
+package rmap
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// default separator of tokens in flattened keys
+const defaultFlattenSeparator = "."
+
+// escapes separator and itself in tokens of flattened keys
+const flattenEscape = `\`
+
+// FlattenOption configures Flatten and Unflatten
+type FlattenOption func(*flattenOptions)
+
+type flattenOptions struct {
+	separator string
+	arrays    bool
+}
+
+// WithFlattenSeparator sets separator of tokens in flattened keys (default is .)
+// It must not be empty or contain \, which escapes separator in keys
+func WithFlattenSeparator(separator string) FlattenOption {
+	return func(o *flattenOptions) {
+		o.separator = separator
+	}
+}
+
+// WithoutArrayIndexes keeps arrays as values in Flatten and makes Unflatten treat integer tokens as object keys
+func WithoutArrayIndexes() FlattenOption {
+	return func(o *flattenOptions) {
+		o.arrays = false
+	}
+}
+
+func newFlattenOptions(opts []FlattenOption) (flattenOptions, error) {
+	options := flattenOptions{separator: defaultFlattenSeparator, arrays: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.separator == "" || strings.Contains(options.separator, flattenEscape) {
+		return flattenOptions{}, errors.Errorf("invalid flatten separator: %q", options.separator)
+	}
+
+	return options, nil
+}
+
+// Flatten returns all values of nested objects and arrays in one-level map under keys in format a.b.0.c
+// Separator and \ in keys are escaped by \, keys of objects, which look like array index, are prefixed by \ (a.\0)
+// Empty objects and arrays are kept as values
+func (r Rmap) Flatten(opts ...FlattenOption) (map[string]interface{}, error) {
+	options, err := newFlattenOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	flat := map[string]interface{}{}
+	_ = walkFlat(r.Mapa, nil, nil, options.arrays, func(path Pointer, tokens []flatToken, value interface{}) error {
+		flat[joinFlatKey(tokens, options.separator, options.arrays)] = value
+		return nil
+	})
+
+	return flat, nil
+}
+
+func (r Rmap) MustFlatten(opts ...FlattenOption) map[string]interface{} {
+	flat, err := r.Flatten(opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return flat
+}
+
+// Unflatten creates Rmap from map returned by Flatten, it is reverse of Flatten with the same options
+// Integer tokens create arrays, index must be lower than number of keys
+// \ which does not escape separator, \ or index-like key is kept as is
+func Unflatten(flat map[string]interface{}, opts ...FlattenOption) (Rmap, error) {
+	options, err := newFlattenOptions(opts)
+	if err != nil {
+		return Rmap{}, err
+	}
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	root := map[string]interface{}{}
+	for _, key := range keys {
+		if _, err := setFlatPath(root, splitFlatKey(key, options.separator), flatValue(DeepCopy(flat[key])), options.arrays, len(flat)); err != nil {
+			return Rmap{}, errors.Wrapf(err, "key: %s", key)
+		}
+	}
+
+	return NewFromMap(resolveFlatNulls(root).(map[string]interface{})), nil
+}
+
+func MustUnflatten(flat map[string]interface{}, opts ...FlattenOption) Rmap {
+	rm, err := Unflatten(flat, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return rm
+}
+
+// flatToken is one token of flattened key, objectKey distinguishes key of object from array index
+type flatToken struct {
+	name      string
+	objectKey bool
+}
+
+// walkFlat calls fn for every value in obj, which is not non-empty object or indexed array
+// path and tokens are the same location, path is used in errors
+func walkFlat(obj map[string]interface{}, path Pointer, tokens []flatToken, arrays bool, fn func(path Pointer, tokens []flatToken, value interface{}) error) error {
+	for key, member := range obj {
+		memberTokens := append(append([]flatToken{}, tokens...), flatToken{name: key, objectKey: true})
+		if err := walkFlatValue(member, path.Append(key), memberTokens, arrays, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walkFlatValue(value interface{}, path Pointer, tokens []flatToken, arrays bool, fn func(path Pointer, tokens []flatToken, value interface{}) error) error {
+	if nested, ok := asObject(value); ok && len(nested) > 0 {
+		return walkFlat(nested, path, tokens, arrays, fn)
+	}
+
+	if arr, ok := asArray(value); ok && arrays && len(arr) > 0 {
+		for index, elem := range arr {
+			elemTokens := append(append([]flatToken{}, tokens...), flatToken{name: strconv.Itoa(index)})
+			if err := walkFlatValue(elem, path.Append(strconv.Itoa(index)), elemTokens, arrays, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fn(path, tokens, value)
+}
+
+// isFlatIndex returns true, if token is array index in canonical form
+func isFlatIndex(token string) bool {
+	if token == "" || (token[0] == '0' && len(token) > 1) {
+		return false
+	}
+
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
+
+// joinFlatKey escapes tokens and joins them by separator, object keys, which look like index, are escaped if arrays is set
+func joinFlatKey(tokens []flatToken, separator string, arrays bool) string {
+	escaped := make([]string, len(tokens))
+	for index, token := range tokens {
+		name := strings.ReplaceAll(token.name, flattenEscape, flattenEscape+flattenEscape)
+		name = strings.ReplaceAll(name, separator, flattenEscape+separator)
+		if arrays && token.objectKey && isFlatIndex(token.name) {
+			name = flattenEscape + name
+		}
+		escaped[index] = name
+	}
+
+	return strings.Join(escaped, separator)
+}
+
+// splitFlatKey splits key on separators, which are not escaped
+// \ followed by digit at start of token marks object key, \ not followed by \, separator or such digit is kept as is
+func splitFlatKey(key, separator string) []flatToken {
+	tokens := []flatToken{}
+	token := flatToken{}
+	name := strings.Builder{}
+
+	for index := 0; index < len(key); {
+		rest := key[index:]
+		switch {
+		case strings.HasPrefix(rest, flattenEscape+separator):
+			name.WriteString(separator)
+			index += len(flattenEscape) + len(separator)
+		case strings.HasPrefix(rest, flattenEscape+flattenEscape):
+			name.WriteString(flattenEscape)
+			index += 2 * len(flattenEscape)
+		case strings.HasPrefix(rest, flattenEscape) && name.Len() == 0 && !token.objectKey && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9':
+			token.objectKey = true
+			index += len(flattenEscape)
+		case strings.HasPrefix(rest, separator):
+			token.name = name.String()
+			tokens = append(tokens, token)
+			token = flatToken{}
+			name.Reset()
+			index += len(separator)
+		default:
+			name.WriteByte(key[index])
+			index++
+		}
+	}
+
+	token.name = name.String()
+	return append(tokens, token)
+}
+
+// flatNull marks explicit null stored by setFlatPath, so it is not confused with missing array element and overwritten
+type flatNull struct{}
+
+// flatValue returns value to be stored by setFlatPath
+func flatValue(value interface{}) interface{} {
+	if value == nil {
+		return flatNull{}
+	}
+
+	return value
+}
+
+// resolveFlatNulls replaces flatNull in tree built by setFlatPath by nil
+func resolveFlatNulls(value interface{}) interface{} {
+	switch v := value.(type) {
+	case flatNull:
+		return nil
+	case map[string]interface{}:
+		for key, member := range v {
+			v[key] = resolveFlatNulls(member)
+		}
+	case []interface{}:
+		for index, elem := range v {
+			v[index] = resolveFlatNulls(elem)
+		}
+	}
+
+	return value
+}
+
+// errFlatConflict is returned by setFlatPath, when value is stored into path of other value
+var errFlatConflict = errors.New("value conflicts with other key")
+
+// setFlatPath stores value into node under path and returns updated node, null must be stored as flatNull
+// Index tokens create arrays, if arrays is true, index must be lower than maxIndex
+func setFlatPath(node interface{}, path []flatToken, value interface{}, arrays bool, maxIndex int) (interface{}, error) {
+	if len(path) == 0 {
+		if node != nil {
+			return nil, errFlatConflict
+		}
+
+		return value, nil
+	}
+
+	token := path[0].name
+	index, _ := strconv.Atoi(token)
+	isIndex := arrays && !path[0].objectKey && isFlatIndex(token)
+
+	if node == nil {
+		if isIndex {
+			node = []interface{}{}
+		} else {
+			node = map[string]interface{}{}
+		}
+	}
+
+	if obj, ok := asObject(node); ok {
+		member, err := setFlatPath(obj[token], path[1:], value, arrays, maxIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		obj[token] = member
+		return obj, nil
+	}
+
+	container, ok := node.([]interface{})
+	if !ok {
+		return nil, errFlatConflict
+	}
+
+	if !isIndex {
+		return nil, errors.Errorf("token: %s is not array index", token)
+	}
+
+	if index >= maxIndex {
+		return nil, errors.Errorf("array index: %d is too large", index)
+	}
+
+	for len(container) <= index {
+		container = append(container, nil)
+	}
+
+	member, err := setFlatPath(container[index], path[1:], value, arrays, maxIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	container[index] = member
+	return container, nil
+}
@@ -0,0 +1,107 @@
+package rmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlatten(t *testing.T) {
+	rm := MustNewFromString(`{"a":{"b":1,"c.d":"x","e\\f":true},"items":[{"name":"n"},2],"empty":{},"none":[],"null":null}`)
+
+	flat, err := rm.Flatten()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a.b":          float64(1),
+		`a.c\.d`:       "x",
+		`a.e\\f`:       true,
+		"items.0.name": "n",
+		"items.1":      float64(2),
+		"empty":        map[string]interface{}{},
+		"none":         []interface{}{},
+		"null":         nil,
+	}, flat)
+
+	unflat, err := Unflatten(flat)
+	assert.Nil(t, err)
+	assert.Equal(t, rm.Mapa, unflat.Mapa)
+
+	flat = rm.MustFlatten(WithFlattenSeparator("__"), WithoutArrayIndexes())
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "n"}, float64(2)}, flat["items"])
+	assert.Equal(t, "x", flat["a__c.d"])
+	assert.Equal(t, rm.Mapa, MustUnflatten(flat, WithFlattenSeparator("__"), WithoutArrayIndexes()).Mapa)
+
+	_, err = rm.Flatten(WithFlattenSeparator(""))
+	assert.NotNil(t, err)
+	assert.Panics(t, func() { rm.MustFlatten(WithFlattenSeparator("")) })
+}
+
+func TestUnflatten(t *testing.T) {
+	// values are copied
+	inner := map[string]interface{}{}
+	rm := MustUnflatten(map[string]interface{}{"a": inner, "a.b": 1})
+	assert.Equal(t, map[string]interface{}{"a": map[string]interface{}{"b": 1}}, rm.Mapa)
+	assert.Empty(t, inner)
+
+	rm = MustUnflatten(map[string]interface{}{"APP_DB_0": "x", "APP_DB_1": "y"}, WithFlattenSeparator("_"))
+	assert.Equal(t, `{"APP":{"DB":["x","y"]}}`, rm.String())
+
+	_, err := Unflatten(map[string]interface{}{"a": 1, "a.b": 2})
+	assert.Equal(t, "key: a.b: value conflicts with other key", err.Error())
+
+	// explicit null conflicts too, but missing array elements are filled
+	_, err = Unflatten(map[string]interface{}{"a": nil, "a.b": 1})
+	assert.Equal(t, "key: a.b: value conflicts with other key", err.Error())
+
+	_, err = Unflatten(map[string]interface{}{"a.0": nil, "a.0.b": 1})
+	assert.Equal(t, "key: a.0.b: value conflicts with other key", err.Error())
+
+	rm = MustUnflatten(map[string]interface{}{"a.1": nil, "a.3": 1, "a.0.b": nil, "c": nil})
+	assert.Equal(t, `{"a":[{"b":null},null,null,1],"c":null}`, rm.String())
+
+	_, err = Unflatten(map[string]interface{}{"a.0": 1, "a.x": 2})
+	assert.Equal(t, "key: a.x: token: x is not array index", err.Error())
+
+	_, err = Unflatten(map[string]interface{}{"a.1000000": 1})
+	assert.Equal(t, "key: a.1000000: array index: 1000000 is too large", err.Error())
+
+	_, err = Unflatten(nil, WithFlattenSeparator(`\`))
+	assert.NotNil(t, err)
+}
+
+func TestFlattenIndexLikeKeys(t *testing.T) {
+	rm := MustNewFromString(`{"status":{"200":"ok","404":"missing","07":"x"},"list":[{"0":"a"}]}`)
+
+	flat := rm.MustFlatten()
+	assert.Equal(t, map[string]interface{}{
+		`status.\200`: "ok",
+		`status.\404`: "missing",
+		"status.07":   "x",
+		`list.0.\0`:   "a",
+	}, flat)
+	assert.Equal(t, rm.Mapa, MustUnflatten(flat).Mapa)
+
+	// without array indexes, keys are not escaped
+	flat = rm.MustFlatten(WithFlattenSeparator("_"), WithoutArrayIndexes())
+	assert.Equal(t, "ok", flat["status_200"])
+	assert.Equal(t, rm.Mapa, MustUnflatten(flat, WithFlattenSeparator("_"), WithoutArrayIndexes()).Mapa)
+}
+
+func TestUnflattenBackslash(t *testing.T) {
+	// backslash, which does not escape anything, is kept
+	rm := MustUnflatten(map[string]interface{}{`C:\dir.x`: 1, `tail\`: 2, `a.b\0`: 3})
+	assert.Equal(t, map[string]interface{}{`C:\dir`: map[string]interface{}{"x": 1}, `tail\`: 2, "a": map[string]interface{}{`b\0`: 3}}, rm.Mapa)
+
+	flat := rm.MustFlatten()
+	assert.Equal(t, rm.Mapa, MustUnflatten(flat).Mapa)
+}
+
+func TestSplitFlatKey(t *testing.T) {
+	for _, key := range []string{"", "a", `a\.b.c`, `\\.x`, `a\\\.b`, `m.\0.1`} {
+		assert.Equal(t, key, joinFlatKey(splitFlatKey(key, "."), ".", true), key)
+	}
+
+	assert.Equal(t, []flatToken{{name: "a.b"}, {name: "c"}}, splitFlatKey(`a\.b.c`, "."))
+	assert.Equal(t, []flatToken{{name: `\`}, {name: "x"}}, splitFlatKey(`\\.x`, "."))
+	assert.Equal(t, []flatToken{{name: "m"}, {name: "0", objectKey: true}, {name: "1"}}, splitFlatKey(`m.\0.1`, "."))
+}
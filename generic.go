@@ -0,0 +1,393 @@
+package rmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ConverterFunc converts value stored in Rmap to type T
+// It must return ErrTypeMismatch, if value has type which cannot be converted at all
+// Any other error is reported as ConversionError
+type ConverterFunc[T any] func(value interface{}) (T, error)
+
+var converters = struct {
+	sync.RWMutex
+	funcs map[reflect.Type]func(value interface{}) (interface{}, error)
+}{
+	funcs: map[reflect.Type]func(value interface{}) (interface{}, error){},
+}
+
+func init() {
+	RegisterConverter(convertString)
+	RegisterConverter(convertBool)
+	RegisterConverter(convertSigned[int])
+	RegisterConverter(convertSigned[int8])
+	RegisterConverter(convertSigned[int16])
+	RegisterConverter(convertSigned[int32])
+	RegisterConverter(convertSigned[int64])
+	RegisterConverter(convertUnsigned[uint])
+	RegisterConverter(convertUnsigned[uint8])
+	RegisterConverter(convertUnsigned[uint16])
+	RegisterConverter(convertUnsigned[uint32])
+	RegisterConverter(convertUnsigned[uint64])
+	RegisterConverter(convertFloat[float32])
+	RegisterConverter(convertFloat[float64])
+	RegisterConverter(convertDecimal)
+	RegisterConverter(convertTime)
+	RegisterConverter(convertRmap)
+	RegisterConverter(convertMap)
+	RegisterConverter(convertSlice)
+	RegisterConverter(convertSliceOf[string])
+	RegisterConverter(convertSliceOf[bool])
+	RegisterConverter(convertSliceOf[int])
+	RegisterConverter(convertSliceOf[int64])
+	RegisterConverter(convertSliceOf[float64])
+	RegisterConverter(convertSliceOf[decimal.Decimal])
+	RegisterConverter(convertSliceOf[Rmap])
+	RegisterConverter(convertMapOf[string])
+	RegisterConverter(convertMapOf[int])
+	RegisterConverter(convertMapOf[float64])
+}
+
+// RegisterConverter registers converter used by generic getters for type T, existing converter for T is replaced
+func RegisterConverter[T any](fn ConverterFunc[T]) {
+	converters.Lock()
+	defer converters.Unlock()
+
+	converters.funcs[typeOf[T]()] = func(value interface{}) (interface{}, error) {
+		return fn(value)
+	}
+}
+
+// Convert converts value to type T, value that already is of type T is returned as is
+// Otherwise converter registered for T is used
+func Convert[T any](value interface{}) (T, error) {
+	var zero T
+
+	if typed, ok := value.(T); ok {
+		return typed, nil
+	}
+
+	converters.RLock()
+	fn, exists := converters.funcs[typeOf[T]()]
+	converters.RUnlock()
+
+	if !exists {
+		return zero, ErrTypeMismatch
+	}
+
+	converted, err := fn(value)
+	if err != nil {
+		return zero, err
+	}
+
+	return converted.(T), nil
+}
+
+// Get gets key converted to type T
+// Numbers are converted between all numeric types (overflow and fraction is error), strings are parsed to decimal.Decimal and RFC3339 time.Time
+func Get[T any](r Rmap, key string) (T, error) {
+	var zero T
+
+	valI, err := r.Get(key)
+	if err != nil {
+		return zero, err
+	}
+
+	return convertAt[T](r, key, valI)
+}
+
+func MustGet[T any](r Rmap, key string) T {
+	val, err := Get[T](r, key)
+	if err != nil {
+		panic(err)
+	}
+
+	return val
+}
+
+// GetOr works like Get, but def is returned if key does not exist
+func GetOr[T any](r Rmap, key string, def T) (T, error) {
+	if !r.Exists(key) {
+		return def, nil
+	}
+
+	return Get[T](r, key)
+}
+
+// GetJPtr gets value on JSONPointer converted to type T, conversion rules are same as in Get
+func GetJPtr[T any](r Rmap, jptr string) (T, error) {
+	var zero T
+
+	valI, err := r.GetJPtr(jptr)
+	if err != nil {
+		return zero, err
+	}
+
+	return convertAt[T](r, jptr, valI)
+}
+
+func MustGetJPtr[T any](r Rmap, jptr string) T {
+	val, err := GetJPtr[T](r, jptr)
+	if err != nil {
+		panic(err)
+	}
+
+	return val
+}
+
+// GetJPtrOr works like GetJPtr, but def is returned if JSONPointer does not exist
+func GetJPtrOr[T any](r Rmap, jptr string, def T) (T, error) {
+	exists, err := r.ExistsJPtr(jptr)
+	if err != nil {
+		return def, err
+	}
+
+	if !exists {
+		return def, nil
+	}
+
+	return GetJPtr[T](r, jptr)
+}
+
+// convertAt converts value and reports errors with path
+func convertAt[T any](r Rmap, path string, value interface{}) (T, error) {
+	converted, err := Convert[T](value)
+	if err == nil {
+		return converted, nil
+	}
+
+	if err == ErrTypeMismatch {
+		return converted, newTypeMismatchError(r, path, -1, typeOf[T]().String(), value)
+	}
+
+	return converted, newConversionError(path, value, typeOf[T]().String(), err)
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func convertString(value interface{}) (string, error) {
+	rv := reflect.ValueOf(value)
+	if _, isNumber := value.(json.Number); isNumber || rv.Kind() != reflect.String {
+		return "", ErrTypeMismatch
+	}
+
+	return rv.String(), nil
+}
+
+func convertBool(value interface{}) (bool, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Bool {
+		return false, ErrTypeMismatch
+	}
+
+	return rv.Bool(), nil
+}
+
+func convertSigned[T int | int8 | int16 | int32 | int64](value interface{}) (T, error) {
+	rv := reflect.ValueOf(value)
+
+	var i int64
+	if num, isNumber := value.(json.Number); isNumber {
+		var err error
+		if i, err = numberToInt64(num); err != nil {
+			return 0, err
+		}
+	} else {
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i = rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if rv.Uint() > math.MaxInt64 {
+				return 0, fmt.Errorf("value overflows %T", T(0))
+			}
+			i = int64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			if f != math.Trunc(f) {
+				return 0, fmt.Errorf("value has fractional part")
+			}
+			if f < math.MinInt64 || f >= math.MaxInt64 {
+				return 0, fmt.Errorf("value overflows %T", T(0))
+			}
+			i = int64(f)
+		default:
+			return 0, ErrTypeMismatch
+		}
+	}
+
+	if int64(T(i)) != i {
+		return 0, fmt.Errorf("value overflows %T", T(0))
+	}
+
+	return T(i), nil
+}
+
+func convertUnsigned[T uint | uint8 | uint16 | uint32 | uint64](value interface{}) (T, error) {
+	rv := reflect.ValueOf(value)
+
+	if num, isNumber := value.(json.Number); isNumber {
+		d, err := decimal.NewFromString(num.String())
+		if err != nil {
+			return 0, err
+		}
+		bi := d.BigInt()
+		if !decimal.NewFromBigInt(bi, 0).Equal(d) {
+			return 0, fmt.Errorf("value has fractional part")
+		}
+		if bi.Sign() < 0 {
+			return 0, fmt.Errorf("negative value cannot be converted to %T", T(0))
+		}
+		if !bi.IsUint64() || uint64(T(bi.Uint64())) != bi.Uint64() {
+			return 0, fmt.Errorf("value overflows %T", T(0))
+		}
+		return T(bi.Uint64()), nil
+	}
+
+	var u uint64
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() < 0 {
+			return 0, fmt.Errorf("negative value cannot be converted to %T", T(0))
+		}
+		u = uint64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u = rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) {
+			return 0, fmt.Errorf("value has fractional part")
+		}
+		if f < 0 || f >= math.MaxUint64 {
+			return 0, fmt.Errorf("value overflows %T", T(0))
+		}
+		u = uint64(f)
+	default:
+		return 0, ErrTypeMismatch
+	}
+
+	if uint64(T(u)) != u {
+		return 0, fmt.Errorf("value overflows %T", T(0))
+	}
+
+	return T(u), nil
+}
+
+func convertFloat[T float32 | float64](value interface{}) (T, error) {
+	f, ok := asNumber(value)
+	if !ok {
+		return 0, ErrTypeMismatch
+	}
+
+	return T(f), nil
+}
+
+func convertDecimal(value interface{}) (decimal.Decimal, error) {
+	if num, isNumber := value.(json.Number); isNumber {
+		return decimal.NewFromString(num.String())
+	}
+
+	if s, err := convertString(value); err == nil {
+		return decimal.NewFromString(s)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return decimal.NewFromInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return decimal.NewFromBigInt(new(big.Int).SetUint64(rv.Uint()), 0), nil
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(rv.Float()) || math.IsInf(rv.Float(), 0) {
+			return decimal.Zero, fmt.Errorf("value is not finite")
+		}
+		return decimal.NewFromFloat(rv.Float()), nil
+	default:
+		return decimal.Zero, ErrTypeMismatch
+	}
+}
+
+func convertTime(value interface{}) (time.Time, error) {
+	s, err := convertString(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.ParseInLocation(time.RFC3339, s, time.UTC)
+}
+
+func convertRmap(value interface{}) (Rmap, error) {
+	obj, ok := asObject(value)
+	if !ok {
+		return Rmap{}, ErrTypeMismatch
+	}
+
+	return NewFromMap(obj), nil
+}
+
+func convertMap(value interface{}) (map[string]interface{}, error) {
+	obj, ok := asObject(value)
+	if !ok {
+		return nil, ErrTypeMismatch
+	}
+
+	return obj, nil
+}
+
+func convertSlice(value interface{}) ([]interface{}, error) {
+	arr, ok := asArray(value)
+	if !ok {
+		return nil, ErrTypeMismatch
+	}
+
+	return arr, nil
+}
+
+// convertSliceOf converts any array to []T, every member is converted by Convert[T]
+func convertSliceOf[T any](value interface{}) ([]T, error) {
+	arr, ok := asArray(value)
+	if !ok {
+		return nil, ErrTypeMismatch
+	}
+
+	out := make([]T, len(arr))
+	for index, elem := range arr {
+		converted, err := Convert[T](elem)
+		if err != nil {
+			return nil, fmt.Errorf("array index: %d: %w", index, err)
+		}
+
+		out[index] = converted
+	}
+
+	return out, nil
+}
+
+// convertMapOf converts any object to map[string]T, every member is converted by Convert[T]
+func convertMapOf[T any](value interface{}) (map[string]T, error) {
+	obj, ok := asObject(value)
+	if !ok {
+		return nil, ErrTypeMismatch
+	}
+
+	out := make(map[string]T, len(obj))
+	for key, elem := range obj {
+		converted, err := Convert[T](elem)
+		if err != nil {
+			return nil, fmt.Errorf("key: %s: %w", key, err)
+		}
+
+		out[key] = converted
+	}
+
+	return out, nil
+}
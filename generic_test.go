@@ -0,0 +1,110 @@
+package rmap
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenericGet(t *testing.T) {
+	rm := NewFromMap(map[string]interface{}{
+		"float":   42.0,
+		"int64":   int64(7),
+		"uint64":  uint64(math.MaxUint64),
+		"string":  "hello",
+		"decimal": "12.34",
+		"time":    "2020-01-02T03:04:05Z",
+		"ints":    []interface{}{1.0, 2.0},
+		"strmap":  map[string]interface{}{"a": "b"},
+		"nested":  map[string]interface{}{"arr": []interface{}{"x"}},
+	})
+
+	assert.Equal(t, 42, MustGet[int](rm, "float"))
+	assert.Equal(t, int64(42), MustGet[int64](rm, "float"))
+	assert.Equal(t, uint(7), MustGet[uint](rm, "int64"))
+	assert.Equal(t, 7.0, MustGet[float64](rm, "int64"))
+	assert.Equal(t, "hello", MustGet[string](rm, "string"))
+	assert.True(t, decimal.RequireFromString("12.34").Equal(MustGet[decimal.Decimal](rm, "decimal")))
+	assert.True(t, decimal.RequireFromString("18446744073709551615").Equal(MustGet[decimal.Decimal](rm, "uint64")))
+	assert.True(t, decimal.NewFromInt(7).Equal(MustGet[decimal.Decimal](rm, "int64")))
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), MustGet[time.Time](rm, "time"))
+	assert.Equal(t, []int{1, 2}, MustGet[[]int](rm, "ints"))
+	assert.Equal(t, map[string]string{"a": "b"}, MustGet[map[string]string](rm, "strmap"))
+	assert.Equal(t, "b", MustGet[Rmap](rm, "strmap").MustGetString("a"))
+	assert.Equal(t, []string{"x"}, MustGetJPtr[[]string](rm, "/nested/arr"))
+	assert.Equal(t, "x", MustGetJPtr[string](rm, "/nested/arr/0"))
+}
+
+func TestGenericGetErrors(t *testing.T) {
+	rm := NewFromMap(map[string]interface{}{
+		"fraction": 1.5,
+		"big":      300.0,
+		"string":   "hello",
+		"negative": -1,
+	})
+
+	_, err := Get[int](rm, "fraction")
+	assert.True(t, errors.Is(err, ErrConversion))
+
+	_, err = Get[int8](rm, "big")
+	assert.True(t, errors.Is(err, ErrConversion))
+
+	_, err = Get[uint](rm, "negative")
+	assert.True(t, errors.Is(err, ErrConversion))
+
+	_, err = Get[int](rm, "string")
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+	assert.True(t, strings.HasPrefix(err.Error(), "key: string is not of type: int"))
+
+	_, err = Get[decimal.Decimal](rm, "string")
+	var conversion *ConversionError
+	assert.True(t, errors.As(err, &conversion))
+	assert.Equal(t, "decimal.Decimal", conversion.Target)
+
+	for _, value := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err = Get[decimal.Decimal](NewFromMap(map[string]interface{}{"value": value}), "value")
+		assert.True(t, errors.Is(err, ErrConversion))
+	}
+
+	_, err = Get[string](rm, "missing")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	_, err = GetJPtr[string](rm, "/missing/deep")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+}
+
+func TestGenericGetOr(t *testing.T) {
+	rm := NewFromMap(map[string]interface{}{"key": "value"})
+
+	val, err := GetOr(rm, "key", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", val)
+
+	val, err = GetOr(rm, "missing", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", val)
+
+	_, err = GetOr(rm, "key", 1)
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+
+	num, err := GetJPtrOr(rm, "/missing", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, num)
+}
+
+type celsius float64
+
+func TestRegisterConverter(t *testing.T) {
+	RegisterConverter(func(value interface{}) (celsius, error) {
+		f, err := Convert[float64](value)
+		return celsius(f), err
+	})
+
+	rm := NewFromMap(map[string]interface{}{"temp": 21.5})
+	assert.Equal(t, celsius(21.5), MustGet[celsius](rm, "temp"))
+}
@@ -8,7 +8,6 @@ require (
 	github.com/qri-io/jsonschema v0.2.1
 	github.com/shopspring/decimal v1.2.0
 	github.com/stretchr/testify v1.4.0
-	github.com/xeipuuv/gojsonpointer v0.0.0-20190905194746-02993c407bfb
 	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
 	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,237 @@
+package rmap
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// SchemaDraft07 is $schema of documents returned by InferSchema
+const SchemaDraft07 = "http://json-schema.org/draft-07/schema#"
+
+// InferOption configures InferSchema
+type InferOption func(*inferOptions)
+
+type inferOptions struct {
+	enumLimit int
+	formats   bool
+}
+
+// WithEnumLimit sets maximum number of distinct values of string property, which is inferred as enum (default 5)
+// Enum is inferred only if every value occurs at least twice on average, 0 disables enums
+func WithEnumLimit(limit int) InferOption {
+	return func(o *inferOptions) {
+		o.enumLimit = limit
+	}
+}
+
+// WithoutFormatDetection disables inference of format keyword
+func WithoutFormatDetection() InferOption {
+	return func(o *inferOptions) {
+		o.formats = false
+	}
+}
+
+// string formats detected by InferSchema, in order of preference
+var inferFormats = []struct {
+	name  string
+	match func(s string) bool
+}{
+	{"date-time", func(s string) bool {
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	}},
+	{"email", regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s.]+$`).MatchString},
+	{"uuid", regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString},
+}
+
+// InferSchema returns draft-07 JSON Schema, which is satisfied by all samples
+// Property is required, if it is present in every object on its location, objects and array items are inferred recursively
+// Strings get enum (see WithEnumLimit) or format (date-time, email, uuid), if all their values have it
+func InferSchema(samples []Rmap, opts ...InferOption) Rmap {
+	options := inferOptions{enumLimit: 5, formats: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	root := newInferNode()
+	for _, sample := range samples {
+		root.add(sample.Mapa, options)
+	}
+
+	schema := root.schema(options)
+	schema["$schema"] = SchemaDraft07
+	if len(samples) == 0 {
+		schema["type"] = "object"
+	}
+
+	return NewFromMap(schema)
+}
+
+// inferNode accumulates all values seen on one location
+type inferNode struct {
+	types map[string]bool
+
+	strings      map[string]int // distinct values, nil after enum limit was exceeded
+	stringCount  int
+	formatCounts map[string]int
+
+	objectCount int
+	properties  map[string]*inferNode
+	presence    map[string]int
+
+	items *inferNode
+}
+
+func newInferNode() *inferNode {
+	return &inferNode{
+		types:        map[string]bool{},
+		strings:      map[string]int{},
+		formatCounts: map[string]int{},
+		properties:   map[string]*inferNode{},
+		presence:     map[string]int{},
+	}
+}
+
+func (n *inferNode) add(value interface{}, options inferOptions) {
+	if obj, ok := asObject(value); ok {
+		n.types["object"] = true
+		n.objectCount++
+		for key, member := range obj {
+			if n.properties[key] == nil {
+				n.properties[key] = newInferNode()
+			}
+			n.presence[key]++
+			n.properties[key].add(member, options)
+		}
+		return
+	}
+
+	if arr, ok := asArray(value); ok {
+		n.types["array"] = true
+		for _, elem := range arr {
+			if n.items == nil {
+				n.items = newInferNode()
+			}
+			n.items.add(elem, options)
+		}
+		return
+	}
+
+	if f, ok := asNumber(value); ok {
+		if f == math.Trunc(f) && !math.IsInf(f, 0) {
+			n.types["integer"] = true
+		} else {
+			n.types["number"] = true
+		}
+		return
+	}
+
+	s, isString := value.(string)
+	if !isString {
+		// other Go types are not valid JSON values and are skipped
+		if name := jsonTypeOf(value); name == "null" || name == "boolean" {
+			n.types[name] = true
+		}
+		return
+	}
+
+	n.types["string"] = true
+	n.stringCount++
+	if n.strings != nil {
+		n.strings[s]++
+		if len(n.strings) > options.enumLimit {
+			n.strings = nil
+		}
+	}
+
+	if options.formats {
+		for _, format := range inferFormats {
+			if format.match(s) {
+				n.formatCounts[format.name]++
+			}
+		}
+	}
+}
+
+func (n *inferNode) schema(options inferOptions) map[string]interface{} {
+	schema := map[string]interface{}{}
+
+	types := make([]string, 0, len(n.types))
+	for name := range n.types {
+		// integers are numbers too
+		if name != "integer" || !n.types["number"] {
+			types = append(types, name)
+		}
+	}
+	sort.Strings(types)
+
+	switch len(types) {
+	case 0:
+		// no value seen, for example items of empty arrays
+	case 1:
+		schema["type"] = types[0]
+	default:
+		typesI := make([]interface{}, 0, len(types))
+		for _, name := range types {
+			typesI = append(typesI, name)
+		}
+		schema["type"] = typesI
+	}
+
+	if n.types["object"] {
+		properties := map[string]interface{}{}
+		required := []interface{}{}
+		for key, node := range n.properties {
+			properties[key] = node.schema(options)
+			if n.presence[key] == n.objectCount {
+				required = append(required, key)
+			}
+		}
+		sort.Slice(required, func(i, j int) bool {
+			return required[i].(string) < required[j].(string)
+		})
+
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	}
+
+	if n.types["array"] && n.items != nil {
+		schema["items"] = n.items.schema(options)
+	}
+
+	if n.types["string"] {
+		n.stringSchema(schema, len(types) > 1)
+	}
+
+	return schema
+}
+
+func (n *inferNode) stringSchema(schema map[string]interface{}, mixed bool) {
+	for _, format := range inferFormats {
+		if n.formatCounts[format.name] == n.stringCount {
+			schema["format"] = format.name
+			return
+		}
+	}
+
+	// enum is not inferred for strings mixed with other types, it would reject them
+	if n.strings == nil || mixed || n.stringCount < 2*len(n.strings) {
+		return
+	}
+
+	values := make([]string, 0, len(n.strings))
+	for value := range n.strings {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	enum := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		enum = append(enum, value)
+	}
+	schema["enum"] = enum
+}
@@ -0,0 +1,34 @@
+package rmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferSchema(t *testing.T) {
+	samples := []Rmap{
+		MustNewFromString(`{"id":"0b8f6a3e-6b0a-4c8e-9f55-2f1d5d3e8a11","status":"active","created":"2024-01-02T03:04:05Z","email":"a@example.com","count":1,"price":1,"tags":["a"],"owner":{"name":"x","age":30}}`),
+		MustNewFromString(`{"id":"7c1c2c52-8f5e-4b4f-a4a4-3f4bb1d1b0a2","status":"inactive","created":"2024-02-03T04:05:06.789+01:00","email":"b@example.org","count":2,"price":2.5,"tags":[],"owner":{"name":"y"},"note":null}`),
+		MustNewFromString(`{"id":"e3b0c442-98fc-4c14-9afb-f4c8996fb924","status":"active","created":"2024-03-04T05:06:07Z","email":"c@example.net","count":3,"price":3,"tags":["b","c"],"owner":{"name":"z","age":40},"note":"n"}`),
+		MustNewFromString(`{"id":"4a5b6c7d-1e2f-4a3b-8c9d-0e1f2a3b4c5d","status":"inactive","created":"2024-04-05T06:07:08Z","email":"d@example.com","count":4,"price":4,"tags":["a"],"owner":{"name":"w"}}`),
+	}
+
+	schema := InferSchema(samples)
+	assert.Equal(t, `{"$schema":"http://json-schema.org/draft-07/schema#","properties":{"count":{"type":"integer"},"created":{"format":"date-time","type":"string"},"email":{"format":"email","type":"string"},"id":{"format":"uuid","type":"string"},"note":{"type":["null","string"]},"owner":{"properties":{"age":{"type":"integer"},"name":{"type":"string"}},"required":["name"],"type":"object"},"price":{"type":"number"},"status":{"enum":["active","inactive"],"type":"string"},"tags":{"items":{"type":"string"},"type":"array"}},"required":["count","created","email","id","owner","price","status","tags"],"type":"object"}`, schema.String())
+
+	for _, sample := range samples {
+		assert.Nil(t, sample.ValidateSchema(schema))
+	}
+
+	// enum limit and format detection can be changed
+	schema = InferSchema(samples, WithEnumLimit(0), WithoutFormatDetection())
+	assert.Equal(t, `{"type":"string"}`, schema.MustGetJPtrRmap("/properties/status").String())
+	assert.Equal(t, `{"type":"string"}`, schema.MustGetJPtrRmap("/properties/id").String())
+
+	// strings, which do not repeat, are not enum
+	schema = InferSchema([]Rmap{MustNewFromString(`{"name":"a"}`), MustNewFromString(`{"name":"b"}`)})
+	assert.Equal(t, `{"type":"string"}`, schema.MustGetJPtrRmap("/properties/name").String())
+
+	assert.Equal(t, `{"$schema":"http://json-schema.org/draft-07/schema#","type":"object"}`, InferSchema(nil).String())
+}
@@ -0,0 +1,558 @@
+package rmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RFC 6902 operation names
+const (
+	PatchOpAdd     = "add"
+	PatchOpRemove  = "remove"
+	PatchOpReplace = "replace"
+	PatchOpMove    = "move"
+	PatchOpCopy    = "copy"
+	PatchOpTest    = "test"
+)
+
+// PatchOperation is one operation of RFC 6902 JSON Patch
+// Path and From are JSONPointers, Value is used only by add, replace and test
+type PatchOperation struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+}
+
+// JSONPatch is RFC 6902 JSON Patch - ordered list of operations
+type JSONPatch []PatchOperation
+
+// MarshalJSON implements Marshaller interface, only members relevant for operation are produced
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{
+		"op":   o.Op,
+		"path": o.Path,
+	}
+
+	switch o.Op {
+	case PatchOpMove, PatchOpCopy:
+		out["from"] = o.From
+	case PatchOpAdd, PatchOpReplace, PatchOpTest:
+		// null is valid value, so it must be always present
+		out["value"] = o.Value
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements Unmarshaller interface and checks that all members required by operation are present
+func (o *PatchOperation) UnmarshalJSON(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.Wrapf(err, "json.Unmarshal() failed")
+	}
+
+	op := PatchOperation{}
+	if err := unmarshalPatchMember(raw, "op", &op.Op); err != nil {
+		return err
+	}
+
+	if err := unmarshalPatchMember(raw, "path", &op.Path); err != nil {
+		return err
+	}
+
+	switch op.Op {
+	case PatchOpRemove:
+	case PatchOpMove, PatchOpCopy:
+		if err := unmarshalPatchMember(raw, "from", &op.From); err != nil {
+			return err
+		}
+	case PatchOpAdd, PatchOpReplace, PatchOpTest:
+		if err := unmarshalPatchMember(raw, "value", &op.Value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown JSON Patch operation: %s", op.Op)
+	}
+
+	*o = op
+	return nil
+}
+
+func unmarshalPatchMember(raw map[string]json.RawMessage, member string, target interface{}) error {
+	value, exists := raw[member]
+	if !exists {
+		return fmt.Errorf("JSON Patch operation is missing member: %s", member)
+	}
+
+	if err := json.Unmarshal(value, target); err != nil {
+		return errors.Wrapf(err, "json.Unmarshal() failed for member: %s", member)
+	}
+
+	return nil
+}
+
+// NewJSONPatchFromBytes parses RFC 6902 JSON Patch document
+func NewJSONPatchFromBytes(data []byte) (JSONPatch, error) {
+	patch := JSONPatch{}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, errors.Wrapf(err, "json.Unmarshal() failed")
+	}
+
+	return patch, nil
+}
+
+func MustNewJSONPatchFromBytes(data []byte) JSONPatch {
+	patch, err := NewJSONPatchFromBytes(data)
+	if err != nil {
+		panic(err)
+	}
+
+	return patch
+}
+
+func (p JSONPatch) Bytes() []byte {
+	byt, _ := json.Marshal(p)
+	return byt
+}
+
+func (p JSONPatch) String() string {
+	return string(p.Bytes())
+}
+
+// ApplyJSONPatch applies RFC 6902 JSON Patch and returns patched copy, r is not modified
+// Operations are applied atomically, if any of them fails (including test), error is returned
+// Go types of values (int, uint64, json.Number, decimal.Decimal, ...) are preserved, objects and arrays are copied to map[string]interface{} and []interface{}
+func (r Rmap) ApplyJSONPatch(patch JSONPatch) (Rmap, error) {
+	doc := patchValue(r.Mapa)
+
+	for index, op := range patch {
+		var err error
+		doc, err = applyPatchOperation(doc, op)
+		if err != nil {
+			return Rmap{}, errors.Wrapf(err, "operation with index: %d (%s %s) failed", index, op.Op, op.Path)
+		}
+	}
+
+	mapa, ok := doc.(map[string]interface{})
+	if !ok {
+		return Rmap{}, fmt.Errorf("patched document is not an OBJECT, but: %T", doc)
+	}
+
+	return NewFromMap(mapa), nil
+}
+
+func (r Rmap) MustApplyJSONPatch(patch JSONPatch) Rmap {
+	patched, err := r.ApplyJSONPatch(patch)
+	if err != nil {
+		panic(err)
+	}
+
+	return patched
+}
+
+// ApplyJSONPatchBytes works like ApplyJSONPatch, but patch is in bytes form
+func (r Rmap) ApplyJSONPatchBytes(patch []byte) (Rmap, error) {
+	decoded, err := NewJSONPatchFromBytes(patch)
+	if err != nil {
+		return Rmap{}, errors.Wrapf(err, "rmap.NewJSONPatchFromBytes() failed")
+	}
+
+	return r.ApplyJSONPatch(decoded)
+}
+
+// CreateJSONPatch returns RFC 6902 JSON Patch that transforms r into changed
+// Objects are compared recursively, arrays are compared by index, other values are compared in JSON form with numbers compared exactly
+// Values in operations keep Go types from changed
+func (r Rmap) CreateJSONPatch(changed Rmap) (JSONPatch, error) {
+	patch := JSONPatch{}
+	createPatchOperations("", patchValue(r.Mapa), patchValue(changed.Mapa), &patch)
+
+	return patch, nil
+}
+
+func (r Rmap) MustCreateJSONPatch(changed Rmap) JSONPatch {
+	patch, err := r.CreateJSONPatch(changed)
+	if err != nil {
+		panic(err)
+	}
+
+	return patch
+}
+
+func createPatchOperations(path string, from, to interface{}, patch *JSONPatch) {
+	switch fromV := from.(type) {
+	case map[string]interface{}:
+		toV, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(fromV))
+		for key := range fromV {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			toVal, exists := toV[key]
+			if !exists {
+				*patch = append(*patch, PatchOperation{Op: PatchOpRemove, Path: path + "/" + escapePointerToken(key)})
+				continue
+			}
+
+			createPatchOperations(path+"/"+escapePointerToken(key), fromV[key], toVal, patch)
+		}
+
+		keys = keys[:0]
+		for key := range toV {
+			if _, exists := fromV[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			*patch = append(*patch, PatchOperation{Op: PatchOpAdd, Path: path + "/" + escapePointerToken(key), Value: toV[key]})
+		}
+
+		return
+	case []interface{}:
+		toV, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+
+		common := len(fromV)
+		if len(toV) < common {
+			common = len(toV)
+		}
+
+		for index := 0; index < common; index++ {
+			createPatchOperations(path+"/"+strconv.Itoa(index), fromV[index], toV[index], patch)
+		}
+
+		// remove from the end, so indexes of remaining elements are not shifted
+		for index := len(fromV) - 1; index >= common; index-- {
+			*patch = append(*patch, PatchOperation{Op: PatchOpRemove, Path: path + "/" + strconv.Itoa(index)})
+		}
+
+		for index := common; index < len(toV); index++ {
+			*patch = append(*patch, PatchOperation{Op: PatchOpAdd, Path: path + "/" + strconv.Itoa(index), Value: toV[index]})
+		}
+
+		return
+	}
+
+	if !patchEqual(from, to) {
+		*patch = append(*patch, PatchOperation{Op: PatchOpReplace, Path: path, Value: to})
+	}
+}
+
+func applyPatchOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	tokens, err := ParsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case PatchOpAdd:
+		return patchAdd(doc, tokens, patchValue(op.Value))
+	case PatchOpRemove:
+		if len(tokens) == 0 {
+			return nil, errors.New("whole document cannot be removed")
+		}
+
+		return walkPatch(doc, tokens, patchRemoveFunc)
+	case PatchOpReplace:
+		value := patchValue(op.Value)
+		if len(tokens) == 0 {
+			return value, nil
+		}
+
+		return walkPatch(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+			if _, err := patchGetChild(parent, token); err != nil {
+				return nil, err
+			}
+
+			return patchSetChild(parent, token, value)
+		})
+	case PatchOpMove:
+		if op.From == op.Path {
+			return doc, nil
+		}
+
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move: %s into its own child: %s", op.From, op.Path)
+		}
+
+		fromTokens, err := ParsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := patchGet(doc, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(fromTokens) == 0 {
+			return nil, errors.New("whole document cannot be moved")
+		}
+
+		if doc, err = walkPatch(doc, fromTokens, patchRemoveFunc); err != nil {
+			return nil, err
+		}
+
+		return patchAdd(doc, tokens, value)
+	case PatchOpCopy:
+		fromTokens, err := ParsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := patchGet(doc, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+
+		// copied value must not share memory with source
+		return patchAdd(doc, tokens, patchValue(value))
+	case PatchOpTest:
+		actual, err := patchGet(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		if !patchEqual(op.Value, actual) {
+			return nil, fmt.Errorf("test failed, value at path: %s is different", op.Path)
+		}
+
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown JSON Patch operation: %s", op.Op)
+	}
+}
+
+// patchValue deep copies value, objects (including Rmap) and arrays are converted to map[string]interface{} and []interface{}, so operations can descend into them
+// Other values keep their Go type, nil maps and slices become null
+func patchValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	if rv := reflect.ValueOf(value); (rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice) && rv.IsNil() {
+		return nil
+	}
+
+	if obj, ok := asObject(value); ok {
+		out := make(map[string]interface{}, len(obj))
+		for key, elem := range obj {
+			out[key] = patchValue(elem)
+		}
+		return out
+	}
+
+	if arr, ok := asArray(value); ok {
+		out := make([]interface{}, len(arr))
+		for index, elem := range arr {
+			out[index] = patchValue(elem)
+		}
+		return out
+	}
+
+	return DeepCopy(value)
+}
+
+// normalizeJSONValue converts any value to the form produced by json.Unmarshal (and deep copies it)
+func normalizeJSONValue(value interface{}) (interface{}, error) {
+	byt, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "json.Marshal() failed")
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(byt, &normalized); err != nil {
+		return nil, errors.Wrapf(err, "json.Unmarshal() failed")
+	}
+
+	return normalized, nil
+}
+
+// normalizeJSONNumbers works like normalizeJSONValue, but numbers are decoded as json.Number, so no precision is lost
+func normalizeJSONNumbers(value interface{}) (interface{}, error) {
+	byt, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "json.Marshal() failed")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(byt))
+	dec.UseNumber()
+
+	var normalized interface{}
+	if err := dec.Decode(&normalized); err != nil {
+		return nil, errors.Wrapf(err, "dec.Decode() failed")
+	}
+
+	return normalized, nil
+}
+
+// patchEqual reports whether a and b are equal in JSON form, numbers are compared exactly, so int 1 and float64 1.0 are equal
+// Value which cannot be marshalled is not equal to anything
+func patchEqual(a, b interface{}) bool {
+	normA, err := normalizeJSONNumbers(a)
+	if err != nil {
+		return false
+	}
+
+	normB, err := normalizeJSONNumbers(b)
+	if err != nil {
+		return false
+	}
+
+	changes := Changes{}
+	diffOptions{numericTypeInsensitive: true}.diff(Pointer{}, normA, normB, &changes)
+	return len(changes) == 0
+}
+
+func patchAdd(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return walkPatch(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch parentV := parent.(type) {
+		case map[string]interface{}:
+			parentV[token] = value
+			return parentV, nil
+		case []interface{}:
+			if token == "-" {
+				return append(parentV, value), nil
+			}
+
+			index, err := patchArrayIndex(token, len(parentV)+1)
+			if err != nil {
+				return nil, err
+			}
+
+			parentV = append(parentV, nil)
+			copy(parentV[index+1:], parentV[index:])
+			parentV[index] = value
+			return parentV, nil
+		default:
+			return nil, fmt.Errorf("cannot add key: %s into: %T", token, parent)
+		}
+	})
+}
+
+func patchRemoveFunc(parent interface{}, token string) (interface{}, error) {
+	if _, err := patchGetChild(parent, token); err != nil {
+		return nil, err
+	}
+
+	switch parentV := parent.(type) {
+	case map[string]interface{}:
+		delete(parentV, token)
+		return parentV, nil
+	case []interface{}:
+		index, _ := strconv.Atoi(token)
+		return append(parentV[:index], parentV[index+1:]...), nil
+	default:
+		return nil, fmt.Errorf("cannot remove key: %s from: %T", token, parent)
+	}
+}
+
+func patchGet(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		var err error
+		if doc, err = patchGetChild(doc, token); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// walkPatch descends to parent of last token and replaces it with result of fn
+// fn may return new parent (arrays are reallocated by insertion)
+func walkPatch(node interface{}, tokens []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+
+	child, err := patchGetChild(node, tokens[0])
+	if err != nil {
+		return nil, err
+	}
+
+	newChild, err := walkPatch(child, tokens[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	return patchSetChild(node, tokens[0], newChild)
+}
+
+func patchGetChild(node interface{}, token string) (interface{}, error) {
+	switch nodeV := node.(type) {
+	case map[string]interface{}:
+		value, exists := nodeV[token]
+		if !exists {
+			return nil, fmt.Errorf("key: %s does not exist", token)
+		}
+
+		return value, nil
+	case []interface{}:
+		index, err := patchArrayIndex(token, len(nodeV))
+		if err != nil {
+			return nil, err
+		}
+
+		return nodeV[index], nil
+	default:
+		return nil, fmt.Errorf("cannot get key: %s from: %T", token, node)
+	}
+}
+
+func patchSetChild(node interface{}, token string, value interface{}) (interface{}, error) {
+	switch nodeV := node.(type) {
+	case map[string]interface{}:
+		nodeV[token] = value
+		return nodeV, nil
+	case []interface{}:
+		index, err := patchArrayIndex(token, len(nodeV))
+		if err != nil {
+			return nil, err
+		}
+
+		nodeV[index] = value
+		return nodeV, nil
+	default:
+		return nil, fmt.Errorf("cannot set key: %s in: %T", token, node)
+	}
+}
+
+// patchArrayIndex parses array index token, valid range is [0, length)
+func patchArrayIndex(token string, length int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return -1, fmt.Errorf("invalid array index: %s", token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return -1, fmt.Errorf("invalid array index: %s", token)
+	}
+
+	if index >= length {
+		return -1, fmt.Errorf("array index: %d out of bounds, length is: %d", index, length)
+	}
+
+	return index, nil
+}
@@ -0,0 +1,111 @@
+package rmap
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	rm := MustNewFromString(`{"name":"foo","tags":["a","b"],"nested":{"value":1}}`)
+
+	patch := MustNewJSONPatchFromBytes([]byte(`[
+		{"op":"test","path":"/name","value":"foo"},
+		{"op":"replace","path":"/name","value":"bar"},
+		{"op":"add","path":"/tags/1","value":"x"},
+		{"op":"add","path":"/tags/-","value":null},
+		{"op":"remove","path":"/tags/0"},
+		{"op":"copy","from":"/nested","path":"/copied"},
+		{"op":"move","from":"/nested/value","path":"/moved"}
+	]`))
+
+	patched, err := rm.ApplyJSONPatch(patch)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"copied":{"value":1},"moved":1,"name":"bar","nested":{},"tags":["x","b",null]}`, patched.String())
+
+	// original is not modified
+	assert.Equal(t, `{"name":"foo","nested":{"value":1},"tags":["a","b"]}`, rm.String())
+}
+
+func TestApplyJSONPatchTestFailed(t *testing.T) {
+	rm := MustNewFromString(`{"name":"foo"}`)
+
+	_, err := rm.ApplyJSONPatchBytes([]byte(`[{"op":"test","path":"/name","value":"bar"},{"op":"remove","path":"/name"}]`))
+	assert.NotNil(t, err)
+	assert.Equal(t, "operation with index: 0 (test /name) failed: test failed, value at path: /name is different", err.Error())
+}
+
+func TestApplyJSONPatchInvalid(t *testing.T) {
+	_, err := NewJSONPatchFromBytes([]byte(`[{"op":"add","path":"/a"}]`))
+	assert.NotNil(t, err)
+
+	rm := MustNewFromString(`{"arr":[1]}`)
+	_, err = rm.ApplyJSONPatch(JSONPatch{{Op: PatchOpRemove, Path: "/arr/1"}})
+	assert.NotNil(t, err)
+
+	_, err = rm.ApplyJSONPatch(JSONPatch{{Op: PatchOpMove, From: "/arr", Path: "/arr/0"}})
+	assert.NotNil(t, err)
+}
+
+func TestCreateJSONPatch(t *testing.T) {
+	original := MustNewFromString(`{"a":1,"b":{"c":"d","e/f":true},"arr":[1,2,3],"gone":"x"}`)
+	changed := MustNewFromString(`{"a":2,"b":{"c":"d","e/f":false},"arr":[1,5],"new":null}`)
+
+	patch, err := original.CreateJSONPatch(changed)
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"op":"replace","path":"/a","value":2},{"op":"replace","path":"/arr/1","value":5},{"op":"remove","path":"/arr/2"},{"op":"replace","path":"/b/e~1f","value":false},{"op":"remove","path":"/gone"},{"op":"add","path":"/new","value":null}]`, patch.String())
+
+	patched, err := original.ApplyJSONPatch(patch)
+	assert.Nil(t, err)
+	assert.Equal(t, changed.String(), patched.String())
+}
+
+func TestJSONPatchKeepsTypes(t *testing.T) {
+	rm := MustNewFromBytesWithOptions([]byte(`{"id":12345678901234567890,"nested":{"amount":0.10}}`), UseNumber())
+	rm.Mapa["int"] = 7
+	rm.Mapa["max"] = uint64(math.MaxUint64)
+	rm.Mapa["price"] = decimal.RequireFromString("1.10")
+	rm.Mapa["sub"] = NewFromMap(map[string]interface{}{"big": int64(math.MaxInt64)})
+
+	patch := MustNewJSONPatchFromBytes([]byte(`[
+		{"op":"test","path":"/int","value":7.0},
+		{"op":"add","path":"/sub/x","value":1},
+		{"op":"copy","from":"/nested","path":"/copied"}
+	]`))
+	patch = append(patch, PatchOperation{Op: PatchOpTest, Path: "/id", Value: json.Number("12345678901234567890")})
+	patched := rm.MustApplyJSONPatch(patch)
+
+	assert.Equal(t, 7, patched.Mapa["int"])
+	assert.Equal(t, uint64(math.MaxUint64), patched.Mapa["max"])
+	assert.Equal(t, decimal.RequireFromString("1.10"), patched.Mapa["price"])
+	assert.Equal(t, json.Number("12345678901234567890"), patched.Mapa["id"])
+	assert.Equal(t, json.Number("0.10"), patched.MustGetJPtr("/copied/amount"))
+	assert.Equal(t, int64(math.MaxInt64), patched.MustGetJPtr("/sub/big"))
+	assert.Equal(t, uint64(math.MaxUint64), MustGet[uint64](patched, "max"))
+
+	// original is not modified
+	assert.Equal(t, 1, len(rm.MustGetRmap("sub").Mapa))
+	_, exists := rm.Mapa["copied"]
+	assert.False(t, exists)
+
+	// 12345678901234567891 is the same float64 as id, but different number
+	_, err := rm.ApplyJSONPatch(JSONPatch{{Op: PatchOpTest, Path: "/id", Value: json.Number("12345678901234567891")}})
+	assert.NotNil(t, err)
+
+	changed := rm.Copy()
+	changed.Mapa["int"] = 8.0
+	changed.Mapa["id"] = json.Number("12345678901234567891")
+
+	patch = rm.MustCreateJSONPatch(changed)
+	assert.Equal(t, `[{"op":"replace","path":"/id","value":12345678901234567891},{"op":"replace","path":"/int","value":8}]`, patch.String())
+	assert.Equal(t, changed.Mapa["id"], rm.MustApplyJSONPatch(patch).Mapa["id"])
+
+	// numbers equal in JSON form are not reported
+	same := rm.Copy()
+	same.Mapa["int"] = 7.0
+	same.Mapa["max"] = json.Number("18446744073709551615")
+	assert.Empty(t, rm.MustCreateJSONPatch(same))
+}
@@ -0,0 +1,1154 @@
+package rmap
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// JSONPath is compiled RFC 9535 JSONPath query, it is safe for concurrent use
+type JSONPath struct {
+	expr     string
+	segments []jpSegment
+}
+
+// CompileJSONPath parses RFC 9535 JSONPath expression, so it can be used multiple times
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	p := &jpParser{expr: expr}
+
+	if !p.consume("$") {
+		return nil, p.errorf("query must start with $")
+	}
+
+	segments, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.expr) {
+		return nil, p.errorf("unexpected character: %q", p.expr[p.pos])
+	}
+
+	return &JSONPath{expr: expr, segments: segments}, nil
+}
+
+func MustCompileJSONPath(expr string) *JSONPath {
+	path, err := CompileJSONPath(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return path
+}
+
+func (j *JSONPath) String() string {
+	return j.expr
+}
+
+// Query returns values of all nodes selected by JSONPath in document order
+// Object members are visited in order of sorted keys
+func (j *JSONPath) Query(r Rmap) []interface{} {
+	return jpApplySegments(j.segments, r.Mapa, r.Mapa)
+}
+
+// Query evaluates RFC 9535 JSONPath expression and returns values of all selected nodes
+func (r Rmap) Query(expr string) ([]interface{}, error) {
+	path, err := CompileJSONPath(expr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "rmap.CompileJSONPath() failed")
+	}
+
+	return path.Query(r), nil
+}
+
+func (r Rmap) MustQuery(expr string) []interface{} {
+	val, err := r.Query(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return val
+}
+
+// QueryString works like Query, but all selected values must be strings
+func (r Rmap) QueryString(expr string) ([]string, error) {
+	iter, err := r.Query(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.iterableToString(iter, expr)
+}
+
+func (r Rmap) MustQueryString(expr string) []string {
+	val, err := r.QueryString(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return val
+}
+
+// QueryRmap works like Query, but all selected values must be objects
+func (r Rmap) QueryRmap(expr string) ([]Rmap, error) {
+	iter, err := r.Query(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]Rmap, len(iter))
+
+	for index, valI := range iter {
+		obj, ok := asObject(valI)
+		if !ok {
+			return nil, newTypeMismatchError(r, expr, index, "OBJECT", valI)
+		}
+
+		output[index] = NewFromMap(obj)
+	}
+
+	return output, nil
+}
+
+func (r Rmap) MustQueryRmap(expr string) []Rmap {
+	val, err := r.QueryRmap(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return val
+}
+
+// QueryOne works like Query, but exactly one node must be selected
+func (r Rmap) QueryOne(expr string) (interface{}, error) {
+	iter, err := r.Query(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iter) != 1 {
+		return nil, fmt.Errorf("JSONPath: %s selected %d nodes, expected exactly one", expr, len(iter))
+	}
+
+	return iter[0], nil
+}
+
+func (r Rmap) MustQueryOne(expr string) interface{} {
+	val, err := r.QueryOne(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return val
+}
+
+type jpSegment struct {
+	descendant bool
+	selectors  []jpSelector
+}
+
+type jpSelector interface {
+	// selectFrom appends nodes selected from node to out
+	selectFrom(node, root interface{}, out []interface{}) []interface{}
+}
+
+type jpNameSelector struct {
+	name string
+}
+
+type jpWildcardSelector struct{}
+
+type jpIndexSelector struct {
+	index int
+}
+
+type jpSliceSelector struct {
+	start, end *int
+	step       int
+}
+
+type jpFilterSelector struct {
+	expr jpLogical
+}
+
+func jpApplySegments(segments []jpSegment, node, root interface{}) []interface{} {
+	nodes := []interface{}{node}
+
+	for _, segment := range segments {
+		next := []interface{}{}
+
+		for _, n := range nodes {
+			if !segment.descendant {
+				for _, sel := range segment.selectors {
+					next = sel.selectFrom(n, root, next)
+				}
+				continue
+			}
+
+			for _, d := range jpDescendants(n, nil) {
+				for _, sel := range segment.selectors {
+					next = sel.selectFrom(d, root, next)
+				}
+			}
+		}
+
+		nodes = next
+	}
+
+	return nodes
+}
+
+// jpDescendants returns node and all its descendants in document order
+func jpDescendants(node interface{}, out []interface{}) []interface{} {
+	out = append(out, node)
+
+	for _, child := range jpChildren(node) {
+		out = jpDescendants(child, out)
+	}
+
+	return out
+}
+
+func jpChildren(node interface{}) []interface{} {
+	if obj, ok := asObject(node); ok {
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		children := make([]interface{}, len(keys))
+		for index, key := range keys {
+			children[index] = obj[key]
+		}
+		return children
+	}
+
+	if arr, ok := asArray(node); ok {
+		return arr
+	}
+
+	return nil
+}
+
+func (s jpNameSelector) selectFrom(node, root interface{}, out []interface{}) []interface{} {
+	if obj, ok := asObject(node); ok {
+		if val, exists := obj[s.name]; exists {
+			out = append(out, val)
+		}
+	}
+
+	return out
+}
+
+func (s jpWildcardSelector) selectFrom(node, root interface{}, out []interface{}) []interface{} {
+	return append(out, jpChildren(node)...)
+}
+
+func (s jpIndexSelector) selectFrom(node, root interface{}, out []interface{}) []interface{} {
+	arr, ok := asArray(node)
+	if !ok {
+		return out
+	}
+
+	index := s.index
+	if index < 0 {
+		index += len(arr)
+	}
+
+	if index >= 0 && index < len(arr) {
+		out = append(out, arr[index])
+	}
+
+	return out
+}
+
+func (s jpSliceSelector) selectFrom(node, root interface{}, out []interface{}) []interface{} {
+	arr, ok := asArray(node)
+	if !ok || s.step == 0 {
+		return out
+	}
+
+	length := len(arr)
+	normalize := func(i int) int {
+		if i < 0 {
+			return length + i
+		}
+		return i
+	}
+	clamp := func(i, low, high int) int {
+		if i < low {
+			return low
+		}
+		if i > high {
+			return high
+		}
+		return i
+	}
+
+	if s.step > 0 {
+		start, end := 0, length
+		if s.start != nil {
+			start = normalize(*s.start)
+		}
+		if s.end != nil {
+			end = normalize(*s.end)
+		}
+
+		for i := clamp(start, 0, length); i < clamp(end, 0, length); i += s.step {
+			out = append(out, arr[i])
+		}
+		return out
+	}
+
+	start, end := length-1, -length-1
+	if s.start != nil {
+		start = normalize(*s.start)
+	}
+	if s.end != nil {
+		end = normalize(*s.end)
+	}
+
+	for i := clamp(start, -1, length-1); clamp(end, -1, length-1) < i; i += s.step {
+		out = append(out, arr[i])
+	}
+	return out
+}
+
+func (s jpFilterSelector) selectFrom(node, root interface{}, out []interface{}) []interface{} {
+	for _, child := range jpChildren(node) {
+		if s.expr.eval(child, root) {
+			out = append(out, child)
+		}
+	}
+
+	return out
+}
+
+// jpLess is true only if both values are numbers or strings and a < b
+func jpLess(a, b interface{}) bool {
+	if aN, ok := asNumber(a); ok {
+		bN, ok := asNumber(b)
+		return ok && aN < bN
+	}
+
+	aS, ok := a.(string)
+	if !ok {
+		return false
+	}
+	bS, ok := b.(string)
+	return ok && aS < bS
+}
+
+// jpLogical is filter expression producing LogicalType
+type jpLogical interface {
+	eval(current, root interface{}) bool
+}
+
+type jpOr []jpLogical
+
+type jpAnd []jpLogical
+
+type jpNot struct {
+	expr jpLogical
+}
+
+// jpExistence is test expression, true if query selects at least one node
+type jpExistence struct {
+	query *jpQuery
+}
+
+// jpFunctionTest is test expression with LogicalType function
+type jpFunctionTest struct {
+	fn *jpFunction
+}
+
+type jpComparison struct {
+	left, right jpOperand
+	op          string
+}
+
+// jpQuery is query embedded in filter, relative to current node (@) or root ($)
+type jpQuery struct {
+	relative bool
+	segments []jpSegment
+}
+
+// jpOperand is comparable or function argument, exactly one member is set
+type jpOperand struct {
+	literal   interface{}
+	isLiteral bool
+	query     *jpQuery
+	fn        *jpFunction
+}
+
+type jpFunction struct {
+	name string
+	args []jpOperand
+}
+
+func (e jpOr) eval(current, root interface{}) bool {
+	for _, sub := range e {
+		if sub.eval(current, root) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e jpAnd) eval(current, root interface{}) bool {
+	for _, sub := range e {
+		if !sub.eval(current, root) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e jpNot) eval(current, root interface{}) bool {
+	return !e.expr.eval(current, root)
+}
+
+func (e jpExistence) eval(current, root interface{}) bool {
+	return len(e.query.nodes(current, root)) > 0
+}
+
+func (e jpFunctionTest) eval(current, root interface{}) bool {
+	return e.fn.logical(current, root)
+}
+
+func (e jpComparison) eval(current, root interface{}) bool {
+	left, leftOk := e.left.value(current, root)
+	right, rightOk := e.right.value(current, root)
+
+	equal := func() bool {
+		if !leftOk || !rightOk {
+			// Nothing is equal only to Nothing
+			return !leftOk && !rightOk
+		}
+		return jsonEqual(left, right)
+	}
+	less := func(a, b interface{}) bool {
+		return leftOk && rightOk && jpLess(a, b)
+	}
+
+	switch e.op {
+	case "==":
+		return equal()
+	case "!=":
+		return !equal()
+	case "<":
+		return less(left, right)
+	case "<=":
+		return less(left, right) || equal()
+	case ">":
+		return less(right, left)
+	case ">=":
+		return less(right, left) || equal()
+	default:
+		return false
+	}
+}
+
+func (q *jpQuery) nodes(current, root interface{}) []interface{} {
+	if q.relative {
+		return jpApplySegments(q.segments, current, root)
+	}
+	return jpApplySegments(q.segments, root, root)
+}
+
+// singular query selects at most one node
+func (q *jpQuery) singular() bool {
+	for _, segment := range q.segments {
+		if segment.descendant || len(segment.selectors) != 1 {
+			return false
+		}
+
+		switch segment.selectors[0].(type) {
+		case jpNameSelector, jpIndexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// value returns false as second value, if result is Nothing
+func (o jpOperand) value(current, root interface{}) (interface{}, bool) {
+	switch {
+	case o.isLiteral:
+		return o.literal, true
+	case o.query != nil:
+		nodes := o.query.nodes(current, root)
+		if len(nodes) != 1 {
+			return nil, false
+		}
+		return nodes[0], true
+	default:
+		return o.fn.value(current, root)
+	}
+}
+
+// function extensions defined by RFC 9535, with their result type
+var jpFunctions = map[string]struct {
+	logical bool
+	args    []string
+}{
+	"length": {false, []string{"value"}},
+	"count":  {false, []string{"nodes"}},
+	"value":  {false, []string{"nodes"}},
+	"match":  {true, []string{"value", "value"}},
+	"search": {true, []string{"value", "value"}},
+}
+
+func (f *jpFunction) value(current, root interface{}) (interface{}, bool) {
+	switch f.name {
+	case "length":
+		arg, ok := f.args[0].value(current, root)
+		if !ok {
+			return nil, false
+		}
+
+		if s, isString := arg.(string); isString {
+			return float64(utf8.RuneCountInString(s)), true
+		}
+		if obj, isObj := asObject(arg); isObj {
+			return float64(len(obj)), true
+		}
+		if arr, isArr := asArray(arg); isArr {
+			return float64(len(arr)), true
+		}
+		return nil, false
+	case "count":
+		return float64(len(f.args[0].query.nodes(current, root))), true
+	case "value":
+		nodes := f.args[0].query.nodes(current, root)
+		if len(nodes) != 1 {
+			return nil, false
+		}
+		return nodes[0], true
+	default:
+		return nil, false
+	}
+}
+
+func (f *jpFunction) logical(current, root interface{}) bool {
+	valI, ok := f.args[0].value(current, root)
+	if !ok {
+		return false
+	}
+	patternI, ok := f.args[1].value(current, root)
+	if !ok {
+		return false
+	}
+
+	val, ok := valI.(string)
+	if !ok {
+		return false
+	}
+	pattern, ok := patternI.(string)
+	if !ok {
+		return false
+	}
+
+	if f.name == "match" {
+		pattern = "^(?:" + pattern + ")$"
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(val)
+}
+
+type jpParser struct {
+	expr string
+	pos  int
+}
+
+func (p *jpParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("JSONPath: %s is invalid at position: %d: %s", p.expr, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *jpParser) peek() byte {
+	if p.pos >= len(p.expr) {
+		return 0
+	}
+	return p.expr[p.pos]
+}
+
+func (p *jpParser) consume(s string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *jpParser) skipBlank() {
+	for p.pos < len(p.expr) {
+		switch p.expr[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jpParser) parseSegments() ([]jpSegment, error) {
+	segments := []jpSegment{}
+
+	for {
+		start := p.pos
+		p.skipBlank()
+
+		switch {
+		case p.consume(".."):
+			var segment jpSegment
+			var err error
+
+			if p.peek() == '[' {
+				segment, err = p.parseBracketed()
+			} else {
+				segment, err = p.parseShorthand()
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			segment.descendant = true
+			segments = append(segments, segment)
+		case p.consume("."):
+			segment, err := p.parseShorthand()
+			if err != nil {
+				return nil, err
+			}
+
+			segments = append(segments, segment)
+		case p.peek() == '[':
+			segment, err := p.parseBracketed()
+			if err != nil {
+				return nil, err
+			}
+
+			segments = append(segments, segment)
+		default:
+			// blank space does not belong to query
+			p.pos = start
+			return segments, nil
+		}
+	}
+}
+
+// parseShorthand parses wildcard or member name following . or ..
+func (p *jpParser) parseShorthand() (jpSegment, error) {
+	if p.consume("*") {
+		return jpSegment{selectors: []jpSelector{jpWildcardSelector{}}}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.expr) {
+		r, size := utf8.DecodeRuneInString(p.expr[p.pos:])
+		isNameChar := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r >= 0x80
+		if !isNameChar && (p.pos == start || r < '0' || r > '9') {
+			break
+		}
+		p.pos += size
+	}
+
+	if p.pos == start {
+		return jpSegment{}, p.errorf("member name or * expected")
+	}
+
+	return jpSegment{selectors: []jpSelector{jpNameSelector{p.expr[start:p.pos]}}}, nil
+}
+
+func (p *jpParser) parseBracketed() (jpSegment, error) {
+	p.pos++ // [
+	segment := jpSegment{}
+
+	for {
+		p.skipBlank()
+
+		sel, err := p.parseSelector()
+		if err != nil {
+			return jpSegment{}, err
+		}
+		segment.selectors = append(segment.selectors, sel)
+
+		p.skipBlank()
+		if p.consume(",") {
+			continue
+		}
+		if p.consume("]") {
+			return segment, nil
+		}
+		return jpSegment{}, p.errorf(", or ] expected")
+	}
+}
+
+func (p *jpParser) parseSelector() (jpSelector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return jpNameSelector{name}, nil
+	case c == '*':
+		p.pos++
+		return jpWildcardSelector{}, nil
+	case c == '?':
+		p.pos++
+		p.skipBlank()
+
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return jpFilterSelector{expr}, nil
+	case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+		return p.parseIndexOrSlice()
+	default:
+		return nil, p.errorf("selector expected")
+	}
+}
+
+func (p *jpParser) parseIndexOrSlice() (jpSelector, error) {
+	start, err := p.parseOptionalInt()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipBlank()
+	if !p.consume(":") {
+		if start == nil {
+			return nil, p.errorf("index expected")
+		}
+		return jpIndexSelector{*start}, nil
+	}
+
+	slice := jpSliceSelector{start: start, step: 1}
+
+	p.skipBlank()
+	if slice.end, err = p.parseOptionalInt(); err != nil {
+		return nil, err
+	}
+
+	p.skipBlank()
+	if p.consume(":") {
+		p.skipBlank()
+
+		step, err := p.parseOptionalInt()
+		if err != nil {
+			return nil, err
+		}
+		if step != nil {
+			slice.step = *step
+		}
+	}
+
+	return slice, nil
+}
+
+// parseOptionalInt parses integer without leading zeroes in I-JSON range, nil is returned if there is no integer
+func (p *jpParser) parseOptionalInt() (*int, error) {
+	start := p.pos
+	p.consume("-")
+
+	digits := p.pos
+	for p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+
+	if p.pos == digits {
+		if p.pos != start {
+			return nil, p.errorf("digit expected")
+		}
+		return nil, nil
+	}
+
+	literal := p.expr[start:p.pos]
+	if (p.expr[digits] == '0' && p.pos-digits > 1) || literal == "-0" {
+		return nil, p.errorf("invalid integer: %s", literal)
+	}
+
+	value, err := strconv.ParseInt(literal, 10, 64)
+	if err != nil || value > 1<<53-1 || value < -(1<<53-1) {
+		return nil, p.errorf("integer: %s out of range", literal)
+	}
+
+	result := int(value)
+	return &result, nil
+}
+
+// parseString parses single or double quoted string literal with JSON escapes
+func (p *jpParser) parseString() (string, error) {
+	quote := p.expr[p.pos]
+	p.pos++
+
+	out := strings.Builder{}
+	for {
+		if p.pos >= len(p.expr) {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.expr[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return out.String(), nil
+		case c < 0x20:
+			return "", p.errorf("control character in string")
+		case c != '\\':
+			out.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		p.pos++
+		esc := p.peek()
+		p.pos++
+
+		switch esc {
+		case 'b':
+			out.WriteByte('\b')
+		case 'f':
+			out.WriteByte('\f')
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 't':
+			out.WriteByte('\t')
+		case '/', '\\', quote:
+			out.WriteByte(esc)
+		case 'u':
+			r, err := p.parseUnicodeEscape()
+			if err != nil {
+				return "", err
+			}
+			out.WriteRune(r)
+		default:
+			return "", p.errorf("invalid escape sequence")
+		}
+	}
+}
+
+func (p *jpParser) parseUnicodeEscape() (rune, error) {
+	hex := func() (rune, error) {
+		if p.pos+4 > len(p.expr) {
+			return 0, p.errorf("invalid unicode escape")
+		}
+
+		value, err := strconv.ParseUint(p.expr[p.pos:p.pos+4], 16, 16)
+		if err != nil {
+			return 0, p.errorf("invalid unicode escape")
+		}
+
+		p.pos += 4
+		return rune(value), nil
+	}
+
+	r, err := hex()
+	if err != nil {
+		return 0, err
+	}
+
+	if utf16.IsSurrogate(r) {
+		if !p.consume(`\u`) {
+			return 0, p.errorf("unpaired surrogate")
+		}
+
+		low, err := hex()
+		if err != nil {
+			return 0, err
+		}
+
+		if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+			return 0, p.errorf("invalid surrogate pair")
+		}
+	}
+
+	return r, nil
+}
+
+func (p *jpParser) parseOr() (jpLogical, error) {
+	expr := jpOr{}
+
+	for {
+		sub, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		expr = append(expr, sub)
+
+		start := p.pos
+		p.skipBlank()
+		if !p.consume("||") {
+			p.pos = start
+			break
+		}
+		p.skipBlank()
+	}
+
+	if len(expr) == 1 {
+		return expr[0], nil
+	}
+	return expr, nil
+}
+
+func (p *jpParser) parseAnd() (jpLogical, error) {
+	expr := jpAnd{}
+
+	for {
+		sub, err := p.parseBasic()
+		if err != nil {
+			return nil, err
+		}
+		expr = append(expr, sub)
+
+		start := p.pos
+		p.skipBlank()
+		if !p.consume("&&") {
+			p.pos = start
+			break
+		}
+		p.skipBlank()
+	}
+
+	if len(expr) == 1 {
+		return expr[0], nil
+	}
+	return expr, nil
+}
+
+func (p *jpParser) parseBasic() (jpLogical, error) {
+	if p.consume("!") {
+		p.skipBlank()
+
+		if p.peek() == '(' {
+			expr, err := p.parseParen()
+			if err != nil {
+				return nil, err
+			}
+			return jpNot{expr}, nil
+		}
+
+		operand, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		expr, err := p.testExpr(operand)
+		if err != nil {
+			return nil, err
+		}
+		return jpNot{expr}, nil
+	}
+
+	if p.peek() == '(' {
+		return p.parseParen()
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	start := p.pos
+	p.skipBlank()
+
+	op := ""
+	for _, candidate := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	if op == "" {
+		p.pos = start
+		return p.testExpr(left)
+	}
+
+	p.skipBlank()
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, operand := range []jpOperand{left, right} {
+		if err := p.checkComparable(operand); err != nil {
+			return nil, err
+		}
+	}
+
+	return jpComparison{left: left, right: right, op: op}, nil
+}
+
+func (p *jpParser) parseParen() (jpLogical, error) {
+	p.pos++ // (
+	p.skipBlank()
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipBlank()
+	if !p.consume(")") {
+		return nil, p.errorf(") expected")
+	}
+
+	return expr, nil
+}
+
+// testExpr converts operand without comparison into test expression
+func (p *jpParser) testExpr(operand jpOperand) (jpLogical, error) {
+	switch {
+	case operand.query != nil:
+		return jpExistence{operand.query}, nil
+	case operand.fn != nil && jpFunctions[operand.fn.name].logical:
+		return jpFunctionTest{operand.fn}, nil
+	case operand.fn != nil:
+		return nil, p.errorf("result of function: %s must be compared", operand.fn.name)
+	default:
+		return nil, p.errorf("literal must be compared")
+	}
+}
+
+func (p *jpParser) checkComparable(operand jpOperand) error {
+	switch {
+	case operand.query != nil && !operand.query.singular():
+		return p.errorf("only singular query can be compared")
+	case operand.fn != nil && jpFunctions[operand.fn.name].logical:
+		return p.errorf("result of function: %s cannot be compared", operand.fn.name)
+	default:
+		return nil
+	}
+}
+
+// parseOperand parses literal, query or function expression
+func (p *jpParser) parseOperand() (jpOperand, error) {
+	c := p.peek()
+
+	switch {
+	case c == '@' || c == '$':
+		p.pos++
+
+		segments, err := p.parseSegments()
+		if err != nil {
+			return jpOperand{}, err
+		}
+
+		return jpOperand{query: &jpQuery{relative: c == '@', segments: segments}}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return jpOperand{}, err
+		}
+
+		return jpOperand{literal: s, isLiteral: true}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for c := p.peek(); c == '_' || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9'); c = p.peek() {
+			p.pos++
+		}
+		name := p.expr[start:p.pos]
+
+		if p.peek() == '(' {
+			return p.parseFunction(name)
+		}
+
+		switch name {
+		case "true":
+			return jpOperand{literal: true, isLiteral: true}, nil
+		case "false":
+			return jpOperand{literal: false, isLiteral: true}, nil
+		case "null":
+			return jpOperand{literal: nil, isLiteral: true}, nil
+		}
+
+		p.pos = start
+		return jpOperand{}, p.errorf("unknown literal: %s", name)
+	default:
+		return jpOperand{}, p.errorf("literal, query or function expected")
+	}
+}
+
+func (p *jpParser) parseNumber() (jpOperand, error) {
+	start := p.pos
+	for c := p.peek(); c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E' || (c >= '0' && c <= '9'); c = p.peek() {
+		p.pos++
+	}
+
+	literal := p.expr[start:p.pos]
+	digits := strings.TrimPrefix(literal, "-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' && digits[1] != 'e' && digits[1] != 'E' {
+		return jpOperand{}, p.errorf("invalid number: %s", literal)
+	}
+
+	value, err := strconv.ParseFloat(literal, 64)
+	if err != nil || math.IsInf(value, 0) || strings.HasSuffix(literal, ".") || strings.Contains(literal, ".e") || strings.HasPrefix(digits, ".") {
+		return jpOperand{}, p.errorf("invalid number: %s", literal)
+	}
+
+	return jpOperand{literal: value, isLiteral: true}, nil
+}
+
+func (p *jpParser) parseFunction(name string) (jpOperand, error) {
+	def, exists := jpFunctions[name]
+	if !exists {
+		return jpOperand{}, p.errorf("unknown function: %s", name)
+	}
+
+	p.pos++ // (
+	fn := &jpFunction{name: name}
+
+	for index := range def.args {
+		p.skipBlank()
+		if index > 0 {
+			if !p.consume(",") {
+				return jpOperand{}, p.errorf("function: %s expects %d arguments", name, len(def.args))
+			}
+			p.skipBlank()
+		}
+
+		arg, err := p.parseOperand()
+		if err != nil {
+			return jpOperand{}, err
+		}
+
+		if def.args[index] == "nodes" && arg.query == nil {
+			return jpOperand{}, p.errorf("argument %d of function: %s must be query", index+1, name)
+		}
+
+		if def.args[index] == "value" {
+			if err := p.checkComparable(arg); err != nil {
+				return jpOperand{}, err
+			}
+		}
+
+		fn.args = append(fn.args, arg)
+	}
+
+	p.skipBlank()
+	if !p.consume(")") {
+		return jpOperand{}, p.errorf("function: %s expects %d arguments", name, len(def.args))
+	}
+
+	return jpOperand{fn: fn}, nil
+}
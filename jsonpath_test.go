@@ -0,0 +1,92 @@
+package rmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var jsonPathDoc = MustNewFromString(`{
+	"store": {
+		"name": "shop",
+		"items": [
+			{"name": "pen", "price": 5, "tags": ["office"]},
+			{"name": "book", "price": 12.5, "tags": ["paper", "office"]},
+			{"name": "lamp", "price": 30, "author": {"name": "ikea"}}
+		]
+	}
+}`)
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []interface{}
+	}{
+		{`$.store.name`, []interface{}{"shop"}},
+		{`$['store']["name"]`, []interface{}{"shop"}},
+		{`$.store.items[0].name`, []interface{}{"pen"}},
+		{`$.store.items[-1].name`, []interface{}{"lamp"}},
+		{`$.store.items[*].price`, []interface{}{5.0, 12.5, 30.0}},
+		{`$.store.items[0:2].name`, []interface{}{"pen", "book"}},
+		{`$.store.items[::-1].name`, []interface{}{"lamp", "book", "pen"}},
+		{`$.store.items[0,2].name`, []interface{}{"pen", "lamp"}},
+		{`$..name`, []interface{}{"shop", "pen", "book", "lamp", "ikea"}},
+		{`$.store.items[?@.price > 10].name`, []interface{}{"book", "lamp"}},
+		{`$.store.items[?@.price >= 5 && @.price < 30].name`, []interface{}{"pen", "book"}},
+		{`$.store.items[?@.author].name`, []interface{}{"lamp"}},
+		{`$.store.items[?!@.author].name`, []interface{}{"pen", "book"}},
+		{`$.store.items[?@.name == 'pen' || @.price == 30].name`, []interface{}{"pen", "lamp"}},
+		{`$.store.items[?length(@.tags) == 2].name`, []interface{}{"book"}},
+		{`$.store.items[?count(@.tags[*]) == 1].name`, []interface{}{"pen"}},
+		{`$.store.items[?match(@.name, 'p.*')].name`, []interface{}{"pen"}},
+		{`$.store.items[?search(@.name, 'o')].name`, []interface{}{"book"}},
+		{`$.store.items[?@.tags[?@ == 'paper']].name`, []interface{}{"book"}},
+		{`$.store.items[?@.price == $.store.items[0].price].name`, []interface{}{"pen"}},
+		{`$.missing`, []interface{}{}},
+	}
+
+	for _, test := range tests {
+		result, err := jsonPathDoc.Query(test.expr)
+		assert.Nil(t, err, test.expr)
+		assert.Equal(t, test.expected, result, test.expr)
+	}
+}
+
+func TestQueryInvalid(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`store`,
+		`$.`,
+		`$[01]`,
+		`$[?@.a == 'x']]`,
+		`$[?@.* == 1]`,
+		`$[?length(@.a)]`,
+		`$[?match(@.a, 'x') == true]`,
+		`$[?unknown(@.a)]`,
+		`$['unterminated]`,
+	} {
+		_, err := jsonPathDoc.Query(expr)
+		assert.NotNil(t, err, expr)
+	}
+}
+
+func TestQueryTyped(t *testing.T) {
+	names, err := jsonPathDoc.QueryString(`$.store.items[*].name`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pen", "book", "lamp"}, names)
+
+	_, err = jsonPathDoc.QueryString(`$.store.items[*].price`)
+	assert.NotNil(t, err)
+
+	items, err := jsonPathDoc.QueryRmap(`$.store.items[?@.price < 10]`)
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "pen", items[0].MustGetString("name"))
+
+	nested := NewFromMap(map[string]interface{}{
+		"list": []Rmap{NewFromMap(map[string]interface{}{"id": 1})},
+	})
+	ids, err := nested.Query(`$.list[*].id`)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1}, ids)
+}
@@ -0,0 +1,181 @@
+package rmap
+
+import (
+	"sort"
+	"strings"
+)
+
+// Missing represents value that does not exist in one of merged documents
+// MergeStrategy can return Missing{} to delete value from result
+type Missing struct{}
+
+// MergeConflict is value changed differently in ours and theirs
+// Base, Ours and Theirs are Missing{}, if value does not exist in respective document
+type MergeConflict struct {
+	Pointer string
+	Base    interface{}
+	Ours    interface{}
+	Theirs  interface{}
+}
+
+// MergeStrategy resolves conflict, it returns resolved value and true, or false if it cannot resolve it
+type MergeStrategy func(conflict MergeConflict) (interface{}, bool)
+
+// PreferOurs resolves every conflict by value from ours
+func PreferOurs(conflict MergeConflict) (interface{}, bool) {
+	return conflict.Ours, true
+}
+
+// PreferTheirs resolves every conflict by value from theirs
+func PreferTheirs(conflict MergeConflict) (interface{}, bool) {
+	return conflict.Theirs, true
+}
+
+// UnionArrays resolves conflict of two arrays by members of ours followed by members of theirs not present in ours
+// Other conflicts are not resolved
+func UnionArrays(conflict MergeConflict) (interface{}, bool) {
+	ours, oursIsArr := asArray(conflict.Ours)
+	theirs, theirsIsArr := asArray(conflict.Theirs)
+	if !oursIsArr || !theirsIsArr {
+		return nil, false
+	}
+
+	return unionArrays(ours, theirs), true
+}
+
+// Merge3Option configures Merge3
+type Merge3Option func(*merge3Options)
+
+type merge3Options struct {
+	strategies map[string]MergeStrategy
+}
+
+// WithMergeStrategy sets strategy for conflicts on JSONPointer jptr and below it, empty jptr sets default strategy
+// Strategy with the longest matching JSONPointer is used
+func WithMergeStrategy(jptr string, strategy MergeStrategy) Merge3Option {
+	return func(o *merge3Options) {
+		o.strategies[jptr] = strategy
+	}
+}
+
+// Merge3 merges changes made in ours and theirs to common base
+// Value changed only on one side is taken from that side, objects are merged recursively, arrays and scalars are merged as a whole
+// Value changed differently on both sides is resolved by strategy (see WithMergeStrategy)
+// Unresolved conflicts are returned sorted by JSONPointer and value from ours is used in merged Rmap
+func Merge3(base, ours, theirs Rmap, opts ...Merge3Option) (Rmap, []MergeConflict) {
+	options := merge3Options{strategies: map[string]MergeStrategy{}}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	conflicts := []MergeConflict{}
+	merged := options.merge(Pointer{}, base.Mapa, ours.Mapa, theirs.Mapa, &conflicts)
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Pointer < conflicts[j].Pointer
+	})
+
+	obj, _ := asObject(merged)
+	return NewFromMap(obj), conflicts
+}
+
+func (o merge3Options) merge(ptr Pointer, base, ours, theirs interface{}, conflicts *[]MergeConflict) interface{} {
+	switch {
+	case mergeEqual(ours, theirs), mergeEqual(base, theirs):
+		return DeepCopy(ours)
+	case mergeEqual(base, ours):
+		return DeepCopy(theirs)
+	}
+
+	oursObj, oursIsObj := asObject(ours)
+	theirsObj, theirsIsObj := asObject(theirs)
+	if oursIsObj && theirsIsObj {
+		baseObj, _ := asObject(base)
+
+		keys := map[string]struct{}{}
+		for _, obj := range []map[string]interface{}{baseObj, oursObj, theirsObj} {
+			for key := range obj {
+				keys[key] = struct{}{}
+			}
+		}
+
+		out := make(map[string]interface{}, len(keys))
+		for key := range keys {
+			value := o.merge(ptr.Append(key), memberOrMissing(baseObj, key), memberOrMissing(oursObj, key), memberOrMissing(theirsObj, key), conflicts)
+			if _, missing := value.(Missing); !missing {
+				out[key] = value
+			}
+		}
+		return out
+	}
+
+	conflict := MergeConflict{Pointer: ptr.String(), Base: base, Ours: ours, Theirs: theirs}
+	if strategy := o.strategyFor(conflict.Pointer); strategy != nil {
+		if resolved, ok := strategy(conflict); ok {
+			return DeepCopy(resolved)
+		}
+	}
+
+	*conflicts = append(*conflicts, conflict)
+	return DeepCopy(ours)
+}
+
+func (o merge3Options) strategyFor(jptr string) MergeStrategy {
+	var strategy MergeStrategy
+	longest := -1
+	for prefix, candidate := range o.strategies {
+		if prefix != "" && jptr != prefix && !strings.HasPrefix(jptr, prefix+"/") {
+			continue
+		}
+
+		if len(prefix) > longest {
+			strategy, longest = candidate, len(prefix)
+		}
+	}
+
+	return strategy
+}
+
+func memberOrMissing(obj map[string]interface{}, key string) interface{} {
+	if value, exists := obj[key]; exists {
+		return value
+	}
+
+	return Missing{}
+}
+
+func mergeEqual(a, b interface{}) bool {
+	_, aMissing := a.(Missing)
+	_, bMissing := b.(Missing)
+	if aMissing || bMissing {
+		return aMissing == bMissing
+	}
+
+	return jsonEqual(a, b)
+}
+
+// unionArrays returns members of a followed by members of b not present in a
+func unionArrays(a, b []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(a)+len(b))
+	for _, elem := range a {
+		out = append(out, DeepCopy(elem))
+	}
+
+	for _, elem := range b {
+		if !containsJSON(out, elem) {
+			out = append(out, DeepCopy(elem))
+		}
+	}
+
+	return out
+}
+
+func containsJSON(arr []interface{}, value interface{}) bool {
+	for _, elem := range arr {
+		if jsonEqual(elem, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package rmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge3(t *testing.T) {
+	base := MustNewFromString(`{"name":"foo","count":1,"tags":["a"],"nested":{"a":1,"b":2},"removed":true}`)
+	ours := MustNewFromString(`{"name":"ours","count":1,"tags":["a","b"],"nested":{"a":10,"b":2},"removed":true}`)
+	theirs := MustNewFromString(`{"name":"theirs","count":2,"tags":["a","c"],"nested":{"a":1,"b":20}}`)
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	assert.Equal(t, `{"count":2,"name":"ours","nested":{"a":10,"b":20},"tags":["a","b"]}`, merged.String())
+	assert.Equal(t, []MergeConflict{
+		{Pointer: "/name", Base: "foo", Ours: "ours", Theirs: "theirs"},
+		{Pointer: "/tags", Base: []interface{}{"a"}, Ours: []interface{}{"a", "b"}, Theirs: []interface{}{"a", "c"}},
+	}, conflicts)
+
+	merged, conflicts = Merge3(base, ours, theirs, WithMergeStrategy("", PreferTheirs), WithMergeStrategy("/tags", UnionArrays))
+	assert.Len(t, conflicts, 0)
+	assert.Equal(t, `{"count":2,"name":"theirs","nested":{"a":10,"b":20},"tags":["a","b","c"]}`, merged.String())
+
+	// base and inputs are not modified
+	assert.Equal(t, `{"count":1,"name":"foo","nested":{"a":1,"b":2},"removed":true,"tags":["a"]}`, base.String())
+}
+
+func TestMerge3CustomResolver(t *testing.T) {
+	base := MustNewFromString(`{"counter":1,"deleted":"x"}`)
+	ours := MustNewFromString(`{"counter":2}`)
+	theirs := MustNewFromString(`{"counter":5,"deleted":"y"}`)
+
+	max := func(conflict MergeConflict) (interface{}, bool) {
+		o, oursOk := asNumber(conflict.Ours)
+		th, theirsOk := asNumber(conflict.Theirs)
+		if !oursOk || !theirsOk {
+			return nil, false
+		}
+		if o > th {
+			return o, true
+		}
+		return th, true
+	}
+
+	merged, conflicts := Merge3(base, ours, theirs, WithMergeStrategy("/counter", max))
+	assert.Equal(t, `{"counter":5}`, merged.String())
+	assert.Equal(t, []MergeConflict{{Pointer: "/deleted", Base: "x", Ours: Missing{}, Theirs: "y"}}, conflicts)
+}
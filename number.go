@@ -0,0 +1,145 @@
+package rmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// DecodeOption configures decoding of JSON document in NewFrom*WithOptions constructors
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	useNumber bool
+}
+
+// UseNumber decodes JSON numbers as json.Number instead of float64, so no precision is lost
+// Numeric getters (GetInt, GetFloat64, GetDecimal, ...) accept json.Number and check it for overflow and fraction
+// Bytes() produces numbers exactly as they were in input
+func UseNumber() DecodeOption {
+	return func(o *decodeOptions) {
+		o.useNumber = true
+	}
+}
+
+// NewFromBytesWithOptions works like NewFromBytes, but decoding can be configured
+func NewFromBytesWithOptions(data []byte, opts ...DecodeOption) (Rmap, error) {
+	options := decodeOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if options.useNumber {
+		dec.UseNumber()
+	}
+
+	mapa := map[string]interface{}{}
+	if err := dec.Decode(&mapa); err != nil {
+		return Rmap{}, errors.Wrap(err, "dec.Decode() failed")
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return Rmap{}, errors.New("unexpected data after top-level JSON object")
+	}
+
+	return NewFromMap(mapa), nil
+}
+
+func MustNewFromBytesWithOptions(data []byte, opts ...DecodeOption) Rmap {
+	rm, err := NewFromBytesWithOptions(data, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return rm
+}
+
+// NewFromReaderWithOptions works like NewFromReader, but decoding can be configured
+func NewFromReaderWithOptions(rdr io.Reader, opts ...DecodeOption) (Rmap, error) {
+	data, err := ioutil.ReadAll(rdr)
+	if err != nil {
+		return Rmap{}, err
+	}
+
+	return NewFromBytesWithOptions(data, opts...)
+}
+
+// numberToInt64 converts json.Number to int64, number with fractional part or out of int64 range is error
+func numberToInt64(num json.Number) (int64, error) {
+	if i, err := num.Int64(); err == nil {
+		return i, nil
+	}
+
+	// number can still be integer in other notation, like 1.0 or 1e3
+	d, err := decimal.NewFromString(num.String())
+	if err != nil {
+		return 0, err
+	}
+
+	bi := d.BigInt()
+	if !decimal.NewFromBigInt(bi, 0).Equal(d) {
+		return 0, fmt.Errorf("number: %s has fractional part", num)
+	}
+
+	if !bi.IsInt64() {
+		return 0, fmt.Errorf("number: %s overflows int64", num)
+	}
+
+	return bi.Int64(), nil
+}
+
+// numberToInt works like numberToInt64, but checks range of int
+func numberToInt(num json.Number) (int, error) {
+	i, err := numberToInt64(num)
+	if err != nil {
+		return 0, err
+	}
+
+	if int64(int(i)) != i {
+		return 0, fmt.Errorf("number: %s overflows int", num)
+	}
+
+	return int(i), nil
+}
+
+// yamlNumbers returns copy of value with json.Number converted to int64, uint64 or float64, so yaml.Marshal writes it as number
+// Number which does not fit any of them is left to yaml.Marshal, which writes it as nearest float64
+func yamlNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			out[key] = yamlNumbers(elem)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for index, elem := range v {
+			out[index] = yamlNumbers(elem)
+		}
+		return out
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u
+		}
+
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+
+		return v
+	default:
+		return v
+	}
+}
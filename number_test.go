@@ -0,0 +1,108 @@
+package rmap
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseNumberRoundTrip(t *testing.T) {
+	input := `{"amount":0.1000000000000000055511,"id":12345678901234567890,"nested":{"arr":[1e3,2.50]}}`
+
+	rm, err := NewFromBytesWithOptions([]byte(input), UseNumber())
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("12345678901234567890"), rm.Mapa["id"])
+	assert.Equal(t, input, rm.String())
+
+	// without option, precision is lost
+	assert.NotEqual(t, input, MustNewFromBytes([]byte(input)).String())
+}
+
+func TestUseNumberGetters(t *testing.T) {
+	rm := MustNewFromBytesWithOptions([]byte(`{"int":42,"exp":1e3,"fraction":1.5,"big":12345678901234567890,"amount":"1.10","nested":{"amount":0.30}}`), UseNumber())
+
+	assert.Equal(t, 42, rm.MustGetInt("int"))
+	assert.Equal(t, 1000, rm.MustGetInt("exp"))
+	assert.Equal(t, 1.5, rm.MustGetFloat64("fraction"))
+	assert.Equal(t, 0.3, rm.MustGetJPtrFloat64("/nested/amount"))
+	assert.True(t, decimal.RequireFromString("0.3").Equal(rm.MustGetJPtrDecimal("/nested/amount")))
+	assert.True(t, decimal.RequireFromString("12345678901234567890").Equal(rm.MustGetDecimal("big")))
+	assert.True(t, decimal.RequireFromString("1.1").Equal(rm.MustGetDecimal("amount")))
+
+	_, err := rm.GetInt("fraction")
+	assert.True(t, errors.Is(err, ErrConversion))
+
+	_, err = rm.GetJPtrInt("/big")
+	assert.True(t, errors.Is(err, ErrConversion))
+
+	_, err = rm.GetString("int")
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+}
+
+func TestUseNumberGeneric(t *testing.T) {
+	rm := MustNewFromBytesWithOptions([]byte(`{"big":12345678901234567890,"negative":-1,"int":7}`), UseNumber())
+
+	assert.Equal(t, uint64(12345678901234567890), MustGet[uint64](rm, "big"))
+	assert.Equal(t, int8(7), MustGet[int8](rm, "int"))
+	assert.Equal(t, 7.0, MustGet[float64](rm, "int"))
+
+	_, err := Get[int64](rm, "big")
+	assert.True(t, errors.Is(err, ErrConversion))
+
+	_, err = Get[uint](rm, "negative")
+	assert.True(t, errors.Is(err, ErrConversion))
+
+	_, err = Get[string](rm, "int")
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+
+	assert.Equal(t, []interface{}{json.Number("7")}, rm.MustQuery("$[?@ == 7]"))
+}
+
+func TestNewFromBytesWithOptionsTrailingData(t *testing.T) {
+	_, err := NewFromBytesWithOptions([]byte(`{"a":1} {"b":2}`), UseNumber())
+	assert.NotNil(t, err)
+
+	_, err = NewFromBytesWithOptions([]byte(`[1]`))
+	assert.NotNil(t, err)
+}
+
+func TestGetDecimalNumber(t *testing.T) {
+	rm := MustNewFromBytes([]byte(`{"float":1.25,"string":"x","object":{}}`))
+	rm.Mapa["int"] = 7
+	rm.Mapa["big"] = uint64(18446744073709551615)
+	rm.Mapa["nan"] = math.NaN()
+
+	assert.True(t, decimal.RequireFromString("1.25").Equal(rm.MustGetDecimal("float")))
+	assert.True(t, decimal.RequireFromString("1.25").Equal(rm.MustGetJPtrDecimal("/float")))
+	assert.True(t, decimal.NewFromInt(7).Equal(rm.MustGetDecimal("int")))
+	assert.True(t, decimal.RequireFromString("18446744073709551615").Equal(rm.MustGetDecimal("big")))
+
+	_, err := rm.GetDecimal("nan")
+	assert.True(t, errors.Is(err, ErrConversion))
+
+	_, err = rm.GetDecimal("string")
+	assert.True(t, errors.Is(err, ErrConversion))
+
+	_, err = rm.GetDecimal("object")
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+}
+
+func TestUseNumberYAML(t *testing.T) {
+	input := `{"int":-3,"big":18446744073709551615,"amount":1.50,"nested":{"arr":[1e3,"2"]}}`
+	expected := "amount: 1.5\nbig: 18446744073709551615\nint: -3\nnested:\n  arr:\n  - 1000\n  - \"2\"\n"
+
+	rm := MustNewFromBytesWithOptions([]byte(input), UseNumber())
+	assert.Equal(t, expected, string(rm.MustYAMLBytes()))
+	assert.Equal(t, json.Number("1.50"), rm.Mapa["amount"])
+
+	back := MustNewFromYAMLBytes(rm.MustYAMLBytes())
+	assert.Equal(t, 1.5, back.MustGetFloat64("amount"))
+	assert.Equal(t, -3, back.MustGetInt("int"))
+
+	ordered := MustNewOrderedFromBytes([]byte(input), UseNumber())
+	assert.Equal(t, "int: -3\nbig: 18446744073709551615\namount: 1.5\nnested:\n  arr:\n  - 1000\n  - \"2\"\n", string(ordered.MustYAMLBytes()))
+}
@@ -0,0 +1,747 @@
+package rmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// OrderedRmap is Rmap, which keeps order of keys of objects from decoded document
+// All mutators and serializers of Rmap are overridden: new keys are appended, deleted keys and array elements are dropped from order
+// and JSON and YAML output uses this order. Keys added directly to Mapa follow ordered keys sorted
+// Methods returning new Rmap (for example ApplyJSONPatch or Redact) and canonical form (CanonicalBytes, Hash, Sign) do not keep order
+type OrderedRmap struct {
+	Rmap
+	order *orderNode
+}
+
+// orderNode records order of keys of one object and order of its members (array elements are stored by index)
+// Tree is addressed by path, so order of deleted or replaced value is dropped together with it
+type orderNode struct {
+	keys     []string
+	children map[string]*orderNode
+}
+
+// child returns node of member token, it is created if create is set
+func (n *orderNode) child(token string, create bool) *orderNode {
+	if n == nil {
+		return nil
+	}
+
+	child := n.children[token]
+	if child == nil && create {
+		child = &orderNode{}
+		n.setChild(token, child)
+	}
+
+	return child
+}
+
+// at returns node of value referenced by ptr
+func (n *orderNode) at(ptr Pointer, create bool) *orderNode {
+	node := n
+	for _, token := range ptr {
+		node = node.child(token, create)
+	}
+
+	return node
+}
+
+// setChild replaces node of member token, nil drops recorded order of member
+func (n *orderNode) setChild(token string, child *orderNode) {
+	if child == nil {
+		delete(n.children, token)
+		return
+	}
+
+	if n.children == nil {
+		n.children = map[string]*orderNode{}
+	}
+	n.children[token] = child
+}
+
+// keysOf returns keys of obj in recorded order, keys, which are not recorded, follow in sorted order
+func (n *orderNode) keysOf(obj map[string]interface{}) []string {
+	out := make([]string, 0, len(obj))
+	seen := make(map[string]bool, len(obj))
+
+	if n != nil {
+		for _, key := range n.keys {
+			if _, present := obj[key]; present && !seen[key] {
+				out = append(out, key)
+				seen[key] = true
+			}
+		}
+	}
+
+	rest := make([]string, 0, len(obj)-len(out))
+	for key := range obj {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(out, rest...)
+}
+
+// addKey appends key, if it is not recorded yet
+func (n *orderNode) addKey(key string) {
+	for _, existing := range n.keys {
+		if existing == key {
+			return
+		}
+	}
+
+	n.keys = append(n.keys, key)
+}
+
+func (n *orderNode) removeKey(key string) {
+	for index, existing := range n.keys {
+		if existing == key {
+			n.keys = append(n.keys[:index:index], n.keys[index+1:]...)
+			return
+		}
+	}
+}
+
+// removeElement drops node of array element at index and shifts nodes of following elements
+func (n *orderNode) removeElement(index, length int) {
+	for i := index; i < length-1; i++ {
+		n.setChild(strconv.Itoa(i), n.children[strconv.Itoa(i+1)])
+	}
+	n.setChild(strconv.Itoa(length-1), nil)
+}
+
+// prune drops recorded order of keys and array elements, which are not present in value
+func (n *orderNode) prune(value interface{}) {
+	if n == nil {
+		return
+	}
+
+	obj, isObj := asObject(value)
+	arr, isArr := asArray(value)
+	for token, child := range n.children {
+		var member interface{}
+		present := false
+		switch {
+		case isObj:
+			member, present = obj[token]
+		case isArr:
+			if index, err := strconv.Atoi(token); err == nil && index < len(arr) {
+				member, present = arr[index], true
+			}
+		}
+
+		if !present {
+			n.setChild(token, nil)
+			continue
+		}
+		child.prune(member)
+	}
+
+	keys := n.keys[:0:0]
+	for _, key := range n.keys {
+		if _, present := obj[key]; present {
+			keys = append(keys, key)
+		}
+	}
+	n.keys = keys
+}
+
+func (n *orderNode) copy() *orderNode {
+	if n == nil {
+		return nil
+	}
+
+	out := &orderNode{keys: append([]string{}, n.keys...)}
+	for token, child := range n.children {
+		out.setChild(token, child.copy())
+	}
+
+	return out
+}
+
+// marshalJSON writes value as JSON, objects are written in recorded order
+func (n *orderNode) marshalJSON(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			buf.WriteString("null")
+			return nil
+		}
+
+		buf.WriteByte('{')
+		for index, key := range n.keysOf(v) {
+			if index > 0 {
+				buf.WriteByte(',')
+			}
+
+			keyBytes, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			buf.Write(keyBytes)
+			buf.WriteByte(':')
+
+			if err := n.child(key, false).marshalJSON(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []interface{}:
+		if v == nil {
+			buf.WriteString("null")
+			return nil
+		}
+
+		buf.WriteByte('[')
+		for index, elem := range v {
+			if index > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := n.child(strconv.Itoa(index), false).marshalJSON(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		return nil
+	}
+}
+
+// toYAML converts value to yaml.MapSlice tree, so yaml.Marshal keeps order of keys
+func (n *orderNode) toYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(yaml.MapSlice, 0, len(v))
+		for _, key := range n.keysOf(v) {
+			out = append(out, yaml.MapItem{Key: key, Value: n.child(key, false).toYAML(v[key])})
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for index, elem := range v {
+			out[index] = n.child(strconv.Itoa(index), false).toYAML(elem)
+		}
+		return out
+	case OrderedRmap:
+		return v.order.toYAML(v.Mapa)
+	case Rmap:
+		return yamlNumbers(v.Mapa)
+	default:
+		return yamlNumbers(v)
+	}
+}
+
+// NewEmptyOrdered creates empty OrderedRmap
+func NewEmptyOrdered() OrderedRmap {
+	return OrderedRmap{Rmap: NewEmpty(), order: &orderNode{}}
+}
+
+// NewOrderedFromBytes creates OrderedRmap from JSON object, only UseNumber() is applicable
+func NewOrderedFromBytes(data []byte, opts ...DecodeOption) (OrderedRmap, error) {
+	return NewOrderedFromReader(bytes.NewReader(data), opts...)
+}
+
+func MustNewOrderedFromBytes(data []byte, opts ...DecodeOption) OrderedRmap {
+	rm, err := NewOrderedFromBytes(data, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return rm
+}
+
+// NewOrderedFromReader creates OrderedRmap from JSON object read from rdr, only UseNumber() is applicable
+func NewOrderedFromReader(rdr io.Reader, opts ...DecodeOption) (OrderedRmap, error) {
+	options := decodeOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	dec := json.NewDecoder(rdr)
+	if options.useNumber {
+		dec.UseNumber()
+	}
+
+	token, err := dec.Token()
+	if err != nil {
+		return OrderedRmap{}, errors.Wrap(err, "dec.Token() failed")
+	}
+
+	if token != json.Delim('{') {
+		return OrderedRmap{}, errors.Errorf("JSON document is not an object, it starts with: %v", token)
+	}
+
+	obj, order, err := decodeOrdered(dec)
+	if err != nil {
+		return OrderedRmap{}, errors.Wrap(err, "decodeOrdered() failed")
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return OrderedRmap{}, errors.New("unexpected data after top-level JSON object")
+	}
+
+	return OrderedRmap{Rmap: NewFromMap(obj), order: order}, nil
+}
+
+// NewOrderedFromYAMLBytes creates OrderedRmap from YAML document
+func NewOrderedFromYAMLBytes(data []byte) (OrderedRmap, error) {
+	out := yaml.MapSlice{}
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return OrderedRmap{}, errors.Wrapf(err, "yaml.Unmarshal() failed")
+	}
+
+	value, order := fromYAMLOrdered(out)
+	obj, _ := value.(map[string]interface{})
+	return OrderedRmap{Rmap: NewFromMap(obj), order: order}, nil
+}
+
+func MustNewOrderedFromYAMLBytes(data []byte) OrderedRmap {
+	rm, err := NewOrderedFromYAMLBytes(data)
+	if err != nil {
+		panic(err)
+	}
+
+	return rm
+}
+
+// NewOrderedFromYAMLFile creates OrderedRmap from YAML file
+func NewOrderedFromYAMLFile(path string) (OrderedRmap, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return OrderedRmap{}, errors.Wrapf(err, "ioutil.ReadFile() failed")
+	}
+
+	return NewOrderedFromYAMLBytes(data)
+}
+
+// fromYAMLOrdered converts yaml.MapSlice to map[string]interface{} and returns its order
+func fromYAMLOrdered(value interface{}) (interface{}, *orderNode) {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		obj := make(map[string]interface{}, len(v))
+		order := &orderNode{}
+		for _, item := range v {
+			key := fmt.Sprint(item.Key)
+			member, memberOrder := fromYAMLOrdered(item.Value)
+			obj[key] = member
+			order.addKey(key)
+			order.setChild(key, memberOrder)
+		}
+		return obj, order
+	case map[interface{}]interface{}:
+		return jsonify(v), nil
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		order := &orderNode{}
+		for index, elem := range v {
+			var elemOrder *orderNode
+			arr[index], elemOrder = fromYAMLOrdered(elem)
+			order.setChild(strconv.Itoa(index), elemOrder)
+		}
+		return arr, order
+	default:
+		return v, nil
+	}
+}
+
+// decodeOrdered decodes JSON object, whose opening token was already read, and returns its order
+func decodeOrdered(dec *json.Decoder) (map[string]interface{}, *orderNode, error) {
+	obj := map[string]interface{}{}
+	order := &orderNode{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key := token.(string)
+		value, valueOrder, err := decodeOrderedValue(dec)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		obj[key] = value
+		order.addKey(key)
+		order.setChild(key, valueOrder)
+	}
+
+	// closing }
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	return obj, order, nil
+}
+
+func decodeOrderedValue(dec *json.Decoder) (interface{}, *orderNode, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		return decodeOrdered(dec)
+	case json.Delim('['):
+		arr := []interface{}{}
+		order := &orderNode{}
+		for dec.More() {
+			elem, elemOrder, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, nil, err
+			}
+			order.setChild(strconv.Itoa(len(arr)), elemOrder)
+			arr = append(arr, elem)
+		}
+
+		// closing ]
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+		return arr, order, nil
+	default:
+		return token, nil, nil
+	}
+}
+
+// record appends last token of ptr to keys of its parent object and replaces recorded order of referenced value
+func (o OrderedRmap) record(ptr Pointer, order *orderNode) {
+	if o.order == nil || len(ptr) == 0 {
+		return
+	}
+
+	parentPtr, token := ptr[:len(ptr)-1], ptr[len(ptr)-1]
+	parentValue, err := parentPtr.Get(o.Mapa)
+	if err != nil {
+		return
+	}
+
+	parent := o.order.at(parentPtr, true)
+	if arr, ok := asArray(parentValue); ok {
+		if token == "-" {
+			token = strconv.Itoa(len(arr) - 1)
+		}
+	} else {
+		parent.addKey(token)
+	}
+
+	parent.setChild(token, order)
+}
+
+// unwrapOrdered returns backing map and copy of order of OrderedRmap value
+func unwrapOrdered(value interface{}) (interface{}, *orderNode) {
+	if ordered, ok := value.(OrderedRmap); ok {
+		return ordered.Mapa, ordered.order.copy()
+	}
+
+	return value, nil
+}
+
+// SetJPtr works like Rmap.SetJPtr, new key is appended, order of OrderedRmap value is kept
+func (o OrderedRmap) SetJPtr(path string, value interface{}) error {
+	ptr, err := ParsePointer(path)
+	if err != nil {
+		return err
+	}
+
+	value, order := unwrapOrdered(value)
+	if err := o.Rmap.SetJPtr(path, value); err != nil {
+		return err
+	}
+
+	o.record(ptr, order)
+	return nil
+}
+
+func (o OrderedRmap) MustSetJPtr(path string, value interface{}) {
+	if err := o.SetJPtr(path, value); err != nil {
+		panic(err)
+	}
+}
+
+// SetJPtrRecursive works like Rmap.SetJPtrRecursive, created objects and new key are appended
+func (o OrderedRmap) SetJPtrRecursive(path string, value interface{}) error {
+	ptr, err := ParsePointer(path)
+	if err != nil {
+		return err
+	}
+
+	// once part of path is missing, all following parts are created
+	missing := []Pointer{}
+	for index := 1; index < len(ptr); index++ {
+		if len(missing) == 0 {
+			if exists, err := ptr[:index].Exists(o.Mapa); err != nil || exists {
+				continue
+			}
+		}
+		missing = append(missing, ptr[:index])
+	}
+
+	value, order := unwrapOrdered(value)
+	if err := o.Rmap.SetJPtrRecursive(path, value); err != nil {
+		return err
+	}
+
+	for _, created := range missing {
+		o.record(created, nil)
+	}
+	o.record(ptr, order)
+	return nil
+}
+
+func (o OrderedRmap) MustSetJPtrRecursive(path string, value interface{}) {
+	if err := o.SetJPtrRecursive(path, value); err != nil {
+		panic(err)
+	}
+}
+
+// DeleteJPtr works like Rmap.DeleteJPtr, recorded order of deleted value is dropped
+func (o OrderedRmap) DeleteJPtr(path string) error {
+	ptr, err := ParsePointer(path)
+	if err != nil {
+		return err
+	}
+
+	length, isArr := 0, false
+	if len(ptr) > 0 {
+		if parentValue, err := ptr[:len(ptr)-1].Get(o.Mapa); err == nil {
+			var arr []interface{}
+			arr, isArr = asArray(parentValue)
+			length = len(arr)
+		}
+	}
+
+	if err := o.Rmap.DeleteJPtr(path); err != nil {
+		return err
+	}
+
+	if len(ptr) == 0 {
+		return nil
+	}
+
+	parent := o.order.at(ptr[:len(ptr)-1], false)
+	if parent == nil {
+		return nil
+	}
+
+	token := ptr[len(ptr)-1]
+	if isArr {
+		index, _ := strconv.Atoi(token)
+		parent.removeElement(index, length)
+		return nil
+	}
+
+	parent.removeKey(token)
+	parent.setChild(token, nil)
+	return nil
+}
+
+func (o OrderedRmap) MustDeleteJPtr(path string) {
+	if err := o.DeleteJPtr(path); err != nil {
+		panic(err)
+	}
+}
+
+// Inject works like Rmap.Inject, Rmap has no order, so injected keys are appended sorted, see InjectOrdered
+func (o OrderedRmap) Inject(path string, value Rmap) error {
+	return o.inject(path, value, nil)
+}
+
+// InjectOrdered works like Inject, injected keys are appended in order of value, order of their values is kept
+func (o OrderedRmap) InjectOrdered(path string, value OrderedRmap) error {
+	return o.inject(path, value.Rmap, value.order)
+}
+
+func (o OrderedRmap) inject(path string, value Rmap, order *orderNode) error {
+	targetExists, err := o.ExistsJPtr(path)
+	if err != nil {
+		return errors.Wrapf(err, "r.ExistsJPtr() failed")
+	}
+
+	if err := o.Rmap.Inject(path, value); err != nil {
+		return err
+	}
+
+	if !targetExists {
+		o.record(MustParsePointer(path), nil)
+	}
+
+	for _, key := range order.keysOf(value.Mapa) {
+		if ptr, err := ParsePointer(path + "/" + key); err == nil {
+			o.record(ptr, order.child(key, false).copy())
+		}
+	}
+
+	return nil
+}
+
+// DeepMerge works like Rmap.DeepMerge, merged keys are appended sorted, order of deleted keys and array elements is dropped
+// Order of array elements follows their indexes
+func (o OrderedRmap) DeepMerge(src Rmap, opts ...DeepMergeOption) error {
+	if err := o.Rmap.DeepMerge(src, opts...); err != nil {
+		return err
+	}
+
+	o.order.prune(o.Mapa)
+	return nil
+}
+
+// ApplySchemaDefaults works like Rmap.ApplySchemaDefaults, defaulted keys are appended sorted
+func (o OrderedRmap) ApplySchemaDefaults(schema Rmap) error {
+	return o.Rmap.ApplySchemaDefaults(schema)
+}
+
+// CoerceToSchema works like Rmap.CoerceToSchema, it changes only scalar values, so order is kept
+func (o OrderedRmap) CoerceToSchema(schema Rmap) error {
+	return o.Rmap.CoerceToSchema(schema)
+}
+
+// EncryptJPtr works like Rmap.EncryptJPtr, order of encrypted values is kept, so DecryptJPtr restores it
+func (o OrderedRmap) EncryptJPtr(ptrs []string, key EncryptionKey) error {
+	return o.Rmap.EncryptJPtr(ptrs, key)
+}
+
+// DecryptJPtr works like Rmap.DecryptJPtr, decrypted values get order recorded before encryption
+func (o OrderedRmap) DecryptJPtr(ptrs []string, key EncryptionKey) error {
+	return o.Rmap.DecryptJPtr(ptrs, key)
+}
+
+// KeysSlice returns keys in order
+func (o OrderedRmap) KeysSlice() []interface{} {
+	output := make([]interface{}, 0, len(o.Mapa))
+	for _, key := range o.KeysSliceString() {
+		output = append(output, key)
+	}
+	return output
+}
+
+// KeysSliceString returns keys in order
+func (o OrderedRmap) KeysSliceString() []string {
+	return o.order.keysOf(o.Mapa)
+}
+
+// Copy returns deep copy of OrderedRmap with its order, see Rmap.Copy()
+func (o OrderedRmap) Copy() OrderedRmap {
+	order := o.order.copy()
+	if order == nil {
+		order = &orderNode{}
+	}
+
+	return OrderedRmap{Rmap: o.Rmap.Copy(), order: order}
+}
+
+// MarshalJSON writes keys in order
+func (o OrderedRmap) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := o.order.marshalJSON(buf, o.Mapa); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (o OrderedRmap) Bytes() []byte {
+	byt, _ := o.MarshalJSON()
+	return byt
+}
+
+func (o OrderedRmap) BytesRef() *[]byte {
+	byt := o.Bytes()
+	return &byt
+}
+
+func (o OrderedRmap) String() string {
+	return string(o.Bytes())
+}
+
+func (o OrderedRmap) ToReader() *bytes.Reader {
+	return bytes.NewReader(o.Bytes())
+}
+
+// Wrap returns OrderedRmap with copy of this one under key
+func (o OrderedRmap) Wrap(key string) OrderedRmap {
+	cp := o.Copy()
+	return NewEmptyOrdered().wrap(key, cp.Mapa, cp.order)
+}
+
+// WrappedResult returns OrderedRmap with this one under key result, it is not copied
+func (o OrderedRmap) WrappedResult() OrderedRmap {
+	return NewEmptyOrdered().wrap("result", o.Mapa, o.order)
+}
+
+func (o OrderedRmap) wrap(key string, value map[string]interface{}, order *orderNode) OrderedRmap {
+	o.Mapa[key] = value
+	o.order.addKey(key)
+	o.order.setChild(key, order)
+	return o
+}
+
+func (o OrderedRmap) WrappedResultBytesRef() *[]byte {
+	byt := o.WrappedResult().Bytes()
+	return &byt
+}
+
+func (o OrderedRmap) WrappedResultBytes() []byte {
+	return o.WrappedResult().Bytes()
+}
+
+// IterableBytes returns JSON of array under key, objects in it keep order
+func (o OrderedRmap) IterableBytes(key string) ([]byte, error) {
+	iter, err := o.GetIterable(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.order.child(key, false).marshalIterable(iter)
+}
+
+// IterableBytesJptr returns JSON of array on JSONPointer, objects in it keep order
+func (o OrderedRmap) IterableBytesJptr(path string) ([]byte, error) {
+	iter, err := o.GetIterableJPtr(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.order.at(MustParsePointer(path), false).marshalIterable(iter)
+}
+
+func (n *orderNode) marshalIterable(iter []interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := n.marshalJSON(buf, iter); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// YAMLBytes writes keys in order
+func (o OrderedRmap) YAMLBytes() ([]byte, error) {
+	return yaml.Marshal(o.order.toYAML(o.Mapa))
+}
+
+func (o OrderedRmap) MustYAMLBytes() []byte {
+	byt, err := o.YAMLBytes()
+	if err != nil {
+		panic(err)
+	}
+
+	return byt
+}
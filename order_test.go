@@ -0,0 +1,180 @@
+package rmap
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedRmapJSON(t *testing.T) {
+	input := `{"zeta":1,"alpha":{"y":true,"x":null},"list":[{"b":1,"a":2}],"mid":"<&>"}`
+
+	rm, err := NewOrderedFromBytes([]byte(input))
+	assert.Nil(t, err)
+	// HTML characters are escaped like in Rmap
+	assert.Equal(t, `{"zeta":1,"alpha":{"y":true,"x":null},"list":[{"b":1,"a":2}],"mid":"\u003c\u0026\u003e"}`, rm.String())
+	assert.Equal(t, []string{"zeta", "alpha", "list", "mid"}, rm.KeysSliceString())
+	assert.Equal(t, []interface{}{"zeta", "alpha", "list", "mid"}, rm.KeysSlice())
+
+	// mutators keep order, new keys are appended
+	assert.Nil(t, rm.SetJPtr("/alpha/new", 1))
+	assert.Nil(t, rm.SetJPtr("/zeta", 2))
+	assert.Nil(t, rm.DeleteJPtr("/alpha/y"))
+	assert.Nil(t, rm.SetJPtrRecursive("/deep/er/est", 3))
+	assert.Nil(t, rm.Inject("/alpha", MustNewFromString(`{"q":1,"p":2}`)))
+	assert.Nil(t, rm.Inject("/injected", MustNewFromString(`{"d":1,"c":2}`)))
+	assert.Nil(t, rm.DeleteJPtr("/mid"))
+	assert.Nil(t, rm.SetJPtr("/mid", MustNewOrderedFromBytes([]byte(`{"n":1,"m":2}`))))
+	assert.Equal(t, `{"zeta":2,"alpha":{"x":null,"new":1,"p":2,"q":1},"list":[{"b":1,"a":2}],"deep":{"er":{"est":3}},"injected":{"c":2,"d":1},"mid":{"n":1,"m":2}}`, rm.String())
+
+	// keys added directly to Mapa are sorted after recorded keys
+	rm.Mapa["c"], rm.Mapa["b"] = 1, 1
+	assert.Equal(t, []string{"zeta", "alpha", "list", "deep", "injected", "mid", "b", "c"}, rm.KeysSliceString())
+
+	// copy keeps order and is independent
+	cp := rm.Copy()
+	assert.Equal(t, rm.String(), cp.String())
+	assert.Nil(t, cp.SetJPtr("/alpha/z", 1))
+	assert.NotEqual(t, rm.String(), cp.String())
+
+	// nested OrderedRmap is marshalled in order
+	wrapped, err := json.Marshal(map[string]interface{}{"doc": rm})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"doc":`+rm.String()+`}`, string(wrapped))
+
+	// use number works together with order
+	rm = MustNewOrderedFromBytes([]byte(`{"b":1.50,"a":12345678901234567890}`), UseNumber())
+	assert.Equal(t, `{"b":1.50,"a":12345678901234567890}`, rm.String())
+
+	_, err = NewOrderedFromBytes([]byte(`[1]`))
+	assert.NotNil(t, err)
+	_, err = NewOrderedFromBytes([]byte(`{"a":1}{}`))
+	assert.NotNil(t, err)
+	_, err = NewOrderedFromBytes([]byte(`{"a":[1,}`))
+	assert.NotNil(t, err)
+}
+
+func TestOrderedRmapReplace(t *testing.T) {
+	rm := MustNewOrderedFromBytes([]byte(`{"obj":{"b":1,"a":2},"arr":[{"d":1,"c":2},{"f":1,"e":2},{"h":1,"g":2}]}`))
+
+	// order of replaced value is dropped, replacement is sorted
+	assert.Nil(t, rm.SetJPtr("/obj", map[string]interface{}{"y": 1, "x": 2}))
+	assert.Equal(t, `{"obj":{"x":2,"y":1},"arr":[{"d":1,"c":2},{"f":1,"e":2},{"h":1,"g":2}]}`, rm.String())
+	assert.Nil(t, rm.order.at(MustParsePointer("/obj"), false))
+
+	// order of following array elements is shifted on delete
+	assert.Nil(t, rm.DeleteJPtr("/arr/0"))
+	assert.Equal(t, `{"obj":{"x":2,"y":1},"arr":[{"f":1,"e":2},{"h":1,"g":2}]}`, rm.String())
+	assert.Nil(t, rm.order.at(MustParsePointer("/arr/2"), false))
+
+	assert.Nil(t, rm.SetJPtr("/arr/-", MustNewOrderedFromBytes([]byte(`{"j":1,"i":2}`))))
+	assert.Equal(t, `{"obj":{"x":2,"y":1},"arr":[{"f":1,"e":2},{"h":1,"g":2},{"j":1,"i":2}]}`, rm.String())
+
+	// order survives changes made by Rmap methods, which keep paths
+	assert.Nil(t, rm.ApplySchemaDefaults(MustNewFromString(`{"properties":{"arr":{"items":{"properties":{"k":{"default":0}}}}}}`)))
+	assert.Equal(t, `{"obj":{"x":2,"y":1},"arr":[{"f":1,"e":2,"k":0},{"h":1,"g":2,"k":0},{"j":1,"i":2,"k":0}]}`, rm.String())
+}
+
+func TestOrderedRmapYAML(t *testing.T) {
+	input := "zeta: 1\nalpha:\n  v: true\n  x: null\nlist:\n- b: 1\n  a: 2\n"
+
+	rm, err := NewOrderedFromYAMLBytes([]byte(input))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"zeta":1,"alpha":{"v":true,"x":null},"list":[{"b":1,"a":2}]}`, rm.String())
+
+	assert.Nil(t, rm.SetJPtr("/alpha/w", "v"))
+	assert.Equal(t, "zeta: 1\nalpha:\n  v: true\n  x: null\n  w: v\nlist:\n- b: 1\n  a: 2\n", string(rm.MustYAMLBytes()))
+
+	empty := NewEmptyOrdered()
+	assert.Nil(t, empty.SetJPtr("/b", 1))
+	assert.Nil(t, empty.SetJPtr("/a", 2))
+	assert.Equal(t, `{"b":1,"a":2}`, empty.String())
+	assert.Equal(t, "b: 1\na: 2\n", string(empty.MustYAMLBytes()))
+}
+
+func TestOrderedRmapArrayDelete(t *testing.T) {
+	rm := MustNewOrderedFromBytes([]byte(`{"arr":[{"b":1,"a":2},{"d":1,"c":2},{"f":1,"e":2}],"n":{"list":[{"h":1,"g":2},{"j":1,"i":2}]}}`))
+
+	rm.MustDeleteJPtr("/arr/0")
+	assert.Nil(t, rm.DeleteJPtr("/n/list/0"))
+	assert.Equal(t, `{"arr":[{"d":1,"c":2},{"f":1,"e":2}],"n":{"list":[{"j":1,"i":2}]}}`, rm.String())
+	assert.Nil(t, rm.order.at(MustParsePointer("/arr/2"), false))
+
+	// new element at index of deleted one does not get its order
+	rm.MustSetJPtr("/arr/-", map[string]interface{}{"b": 1, "a": 2})
+	assert.Equal(t, `{"arr":[{"d":1,"c":2},{"f":1,"e":2},{"a":2,"b":1}],"n":{"list":[{"j":1,"i":2}]}}`, rm.String())
+
+	// merge drops order of deleted keys and removed elements
+	assert.Nil(t, rm.DeepMerge(MustNewFromString(`{"arr":[{"y":1,"x":2}],"n":null,"z":1}`), WithNullHandling(NullDeletes)))
+	assert.Equal(t, `{"arr":[{"x":2,"y":1}],"z":1}`, rm.String())
+	assert.Nil(t, rm.order.at(MustParsePointer("/arr/1"), false))
+	assert.Nil(t, rm.order.at(MustParsePointer("/n"), false))
+	assert.Equal(t, []string{"arr", "z"}, rm.KeysSliceString())
+}
+
+func TestOrderedRmapInject(t *testing.T) {
+	rm := MustNewOrderedFromBytes([]byte(`{"target":{"z":1}}`))
+
+	assert.Nil(t, rm.InjectOrdered("/target", MustNewOrderedFromBytes([]byte(`{"q":1,"p":{"n":1,"m":2}}`))))
+	assert.Nil(t, rm.InjectOrdered("/new", MustNewOrderedFromBytes([]byte(`{"d":1,"c":2}`))))
+	assert.Equal(t, `{"target":{"z":1,"q":1,"p":{"n":1,"m":2}},"new":{"d":1,"c":2}}`, rm.String())
+
+	// plain Rmap has no order, keys are sorted
+	assert.Nil(t, rm.Inject("/plain", MustNewFromString(`{"d":1,"c":2}`)))
+	assert.Equal(t, `{"target":{"z":1,"q":1,"p":{"n":1,"m":2}},"new":{"d":1,"c":2},"plain":{"c":2,"d":1}}`, rm.String())
+}
+
+func TestOrderedRmapSerializers(t *testing.T) {
+	rm := MustNewOrderedFromBytes([]byte(`{"b":1,"a":[{"d":1,"c":2}]}`))
+
+	assert.Equal(t, rm.String(), string(*rm.BytesRef()))
+	assert.Equal(t, `{"result":{"b":1,"a":[{"d":1,"c":2}]}}`, string(rm.WrappedResultBytes()))
+	assert.Equal(t, `{"result":{"b":1,"a":[{"d":1,"c":2}]}}`, string(*rm.WrappedResultBytesRef()))
+	assert.Equal(t, `{"w":{"b":1,"a":[{"d":1,"c":2}]}}`, rm.Wrap("w").String())
+
+	byt, err := ioutil.ReadAll(rm.ToReader())
+	assert.Nil(t, err)
+	assert.Equal(t, rm.String(), string(byt))
+
+	byt, err = rm.IterableBytes("a")
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"d":1,"c":2}]`, string(byt))
+
+	byt, err = rm.IterableBytesJptr("/a")
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"d":1,"c":2}]`, string(byt))
+}
+
+// promoted methods of Rmap, which do not modify document or serialize it with order
+var orderedRmapPromoted = map[string]bool{
+	"ApplyJSONPatch": true, "ApplyJSONPatchBytes": true, "ApplyMergePatch": true, "ApplyMergePatchBytes": true,
+	"CreateJSONPatch": true, "CreateMergePatch": true, "MustApplyJSONPatch": true, "MustCreateJSONPatch": true,
+	"CanonicalBytes": true, "MustCanonicalBytes": true, "CanonicalHash": true, "Hash": true, "HashWith": true, "MustHashWith": true,
+	"Sign": true, "MustSign": true, "Verify": true, "Decode": true, "MustDecode": true, "Diff": true, "Redact": true,
+	"Flatten": true, "MustFlatten": true, "ToStringMap": true, "IsEmpty": true, "IsValidJSONSchema": true, "IsEncryptedJPtr": true,
+	"ValidateSchema": true, "ValidateSchemaBytes": true, "ConvertToInt": true, "MustConvertToInt": true,
+	"Exists": true, "ExistsMany": true, "ExistsJPtr": true, "MustExistsJPtr": true,
+	"Contains": true, "ContainsJPtr": true, "ContainsJPtrKV": true,
+	"Query": true, "MustQuery": true, "QueryOne": true, "MustQueryOne": true, "QueryRmap": true, "MustQueryRmap": true,
+	"QueryString": true, "MustQueryString": true,
+}
+
+func TestOrderedRmapOverrides(t *testing.T) {
+	typ := reflect.TypeOf(OrderedRmap{})
+	for index := 0; index < typ.NumMethod(); index++ {
+		method := typ.Method(index)
+		pc := method.Func.Pointer()
+		file, _ := runtime.FuncForPC(pc).FileLine(pc)
+		isGetter := strings.HasPrefix(method.Name, "Get") || strings.HasPrefix(method.Name, "MustGet")
+
+		// methods promoted from Rmap are wrapped by compiler
+		if file == "<autogenerated>" && !isGetter && !orderedRmapPromoted[method.Name] {
+			t.Errorf("Rmap.%s is not overridden by OrderedRmap", method.Name)
+		}
+	}
+}
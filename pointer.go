@@ -0,0 +1,367 @@
+package rmap
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Pointer is parsed RFC 6901 JSONPointer, it holds unescaped reference tokens
+// Empty Pointer references whole document
+// Pointer can traverse map[string]interface{}, Rmap, any other map with string keys (map[string]string) and any slice ([]interface{}, []Rmap)
+type Pointer []string
+
+// ParsePointer parses JSONPointer string, ~1 and ~0 escapes are decoded
+func ParsePointer(s string) (Pointer, error) {
+	if s == "" {
+		return Pointer{}, nil
+	}
+
+	if !strings.HasPrefix(s, "/") {
+		return nil, &InvalidPointerError{Pointer: s, Reason: "must be empty or start with /"}
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for index, token := range tokens {
+		for pos := 0; pos < len(token); pos++ {
+			if token[pos] == '~' && (pos+1 == len(token) || (token[pos+1] != '0' && token[pos+1] != '1')) {
+				return nil, &InvalidPointerError{Pointer: s, Reason: "~ must be followed by 0 or 1"}
+			}
+		}
+
+		tokens[index] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	return Pointer(tokens), nil
+}
+
+func MustParsePointer(s string) Pointer {
+	ptr, err := ParsePointer(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return ptr
+}
+
+// NewPointer creates Pointer from unescaped tokens
+func NewPointer(tokens ...string) Pointer {
+	return Pointer(tokens)
+}
+
+// String returns escaped JSONPointer
+func (p Pointer) String() string {
+	out := strings.Builder{}
+	for _, token := range p {
+		out.WriteByte('/')
+		out.WriteString(escapePointerToken(token))
+	}
+	return out.String()
+}
+
+// Append returns new Pointer with tokens added at the end
+func (p Pointer) Append(tokens ...string) Pointer {
+	out := make(Pointer, 0, len(p)+len(tokens))
+	out = append(out, p...)
+	return append(out, tokens...)
+}
+
+// Get returns value referenced by Pointer
+func (p Pointer) Get(document interface{}) (interface{}, error) {
+	node := document
+
+	for index := range p {
+		var err error
+		if node, err = p.child(node, index); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+// Exists returns false without error if referenced object key or array index does not exist
+func (p Pointer) Exists(document interface{}) (bool, error) {
+	if _, err := p.Get(document); err != nil {
+		if _, ok := err.(*KeyNotFoundError); ok {
+			return false, nil
+		}
+		if _, ok := err.(*IndexOutOfRangeError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Set sets value referenced by Pointer, parent of referenced value must exist
+// Object key is created if it doesn't exist, array element is replaced, - token appends to array
+func (p Pointer) Set(document interface{}, value interface{}) error {
+	if len(p) == 0 {
+		return &InvalidPointerError{Pointer: "", Reason: "whole document cannot be set"}
+	}
+
+	_, err := p.modify(document, 0, func(parent interface{}, index int) (interface{}, error) {
+		return p.setChild(parent, index, value)
+	})
+	return err
+}
+
+// Delete removes value referenced by Pointer, array elements after removed one are shifted
+func (p Pointer) Delete(document interface{}) error {
+	if len(p) == 0 {
+		return &InvalidPointerError{Pointer: "", Reason: "whole document cannot be deleted"}
+	}
+
+	_, err := p.modify(document, 0, p.deleteChild)
+	return err
+}
+
+// modify descends to parent of last token and calls fn on it
+// fn may return reallocated parent (slice), which is stored back in its own parent
+func (p Pointer) modify(node interface{}, index int, fn func(parent interface{}, index int) (interface{}, error)) (interface{}, error) {
+	if index == len(p)-1 {
+		newNode, err := fn(node, index)
+		if err != nil {
+			return nil, err
+		}
+
+		if index == 0 && reflect.ValueOf(node).Kind() == reflect.Slice && reflect.ValueOf(newNode).Len() != reflect.ValueOf(node).Len() {
+			return nil, &InvalidPointerError{Pointer: p.String(), Reason: "length of root array cannot be changed"}
+		}
+
+		return newNode, nil
+	}
+
+	child, err := p.child(node, index)
+	if err != nil {
+		return nil, err
+	}
+
+	newChild, err := p.modify(child, index+1, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	if reflect.ValueOf(child).Kind() != reflect.Slice {
+		// maps are modified in place
+		return node, nil
+	}
+
+	return p.setChild(node, index, newChild)
+}
+
+// child returns value referenced by p[index] in node
+func (p Pointer) child(node interface{}, index int) (interface{}, error) {
+	token := p[index]
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		value, exists := n[token]
+		if !exists {
+			return nil, &KeyNotFoundError{Path: p.String(), Missing: p[:index+1].String()}
+		}
+		return value, nil
+	case Rmap:
+		return p.child(n.Mapa, index)
+	case []interface{}:
+		i, err := p.arrayIndex(index, len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		return n[i], nil
+	}
+
+	rv := reflect.ValueOf(node)
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		value := rv.MapIndex(reflect.ValueOf(token).Convert(rv.Type().Key()))
+		if !value.IsValid() {
+			return nil, &KeyNotFoundError{Path: p.String(), Missing: p[:index+1].String()}
+		}
+		return value.Interface(), nil
+	case rv.Kind() == reflect.Slice:
+		i, err := p.arrayIndex(index, rv.Len(), false)
+		if err != nil {
+			return nil, err
+		}
+		return rv.Index(i).Interface(), nil
+	default:
+		return nil, p.notContainerError(node, index)
+	}
+}
+
+// setChild sets value referenced by p[index] in node and returns node (reallocated, if it is slice and value was appended)
+func (p Pointer) setChild(node interface{}, index int, value interface{}) (interface{}, error) {
+	token := p[index]
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		n[token] = value
+		return n, nil
+	case Rmap:
+		n.Mapa[token] = value
+		return n, nil
+	case []interface{}:
+		i, err := p.arrayIndex(index, len(n), true)
+		if err != nil {
+			return nil, err
+		}
+		if i == len(n) {
+			return append(n, value), nil
+		}
+		n[i] = value
+		return n, nil
+	}
+
+	rv := reflect.ValueOf(node)
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		converted, err := p.convertValue(value, rv.Type().Elem(), index)
+		if err != nil {
+			return nil, err
+		}
+		rv.SetMapIndex(reflect.ValueOf(token).Convert(rv.Type().Key()), converted)
+		return node, nil
+	case rv.Kind() == reflect.Slice:
+		i, err := p.arrayIndex(index, rv.Len(), true)
+		if err != nil {
+			return nil, err
+		}
+
+		converted, err := p.convertValue(value, rv.Type().Elem(), index)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == rv.Len() {
+			return reflect.Append(rv, converted).Interface(), nil
+		}
+		rv.Index(i).Set(converted)
+		return node, nil
+	default:
+		return nil, p.notContainerError(node, index)
+	}
+}
+
+// deleteChild removes value referenced by p[index] from node and returns node (reallocated, if it is slice)
+func (p Pointer) deleteChild(node interface{}, index int) (interface{}, error) {
+	if _, err := p.child(node, index); err != nil {
+		return nil, err
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		delete(n, p[index])
+		return n, nil
+	case Rmap:
+		delete(n.Mapa, p[index])
+		return n, nil
+	}
+
+	rv := reflect.ValueOf(node)
+
+	if rv.Kind() == reflect.Map {
+		rv.SetMapIndex(reflect.ValueOf(p[index]).Convert(rv.Type().Key()), reflect.Value{})
+		return node, nil
+	}
+
+	// index was validated by p.child()
+	i, _ := strconv.Atoi(p[index])
+	out := reflect.MakeSlice(rv.Type(), 0, rv.Len()-1)
+	out = reflect.AppendSlice(out, rv.Slice(0, i))
+	out = reflect.AppendSlice(out, rv.Slice(i+1, rv.Len()))
+	return out.Interface(), nil
+}
+
+// convertValue converts value, so it can be stored in typed map or slice
+func (p Pointer) convertValue(value interface{}, target reflect.Type, index int) (reflect.Value, error) {
+	switch v := value.(type) {
+	case Rmap:
+		if target == reflect.TypeOf(map[string]interface{}{}) {
+			value = v.Mapa
+		}
+	case map[string]interface{}:
+		if target == reflect.TypeOf(Rmap{}) {
+			value = NewFromMap(v)
+		}
+	}
+
+	if value == nil {
+		switch target.Kind() {
+		case reflect.Interface, reflect.Map, reflect.Slice, reflect.Ptr:
+			return reflect.Zero(target), nil
+		}
+	} else if rv := reflect.ValueOf(value); rv.Type().AssignableTo(target) {
+		return rv, nil
+	}
+
+	return reflect.Value{}, &TypeMismatchError{
+		Path:     p[:index+1].String(),
+		Index:    -1,
+		Expected: target.String(),
+		Actual:   fmt.Sprintf("%T", value),
+	}
+}
+
+// arrayIndex parses p[index] as array index, - is accepted only if allowAppend is set and it is returned as length
+func (p Pointer) arrayIndex(index, length int, allowAppend bool) (int, error) {
+	token := p[index]
+
+	if token == "-" {
+		if allowAppend {
+			return length, nil
+		}
+		return -1, &IndexOutOfRangeError{Pointer: p[:index+1].String(), Index: length, Length: length}
+	}
+
+	valid := token != "" && (token == "0" || token[0] != '0')
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			valid = false
+			break
+		}
+	}
+
+	i, err := strconv.Atoi(token)
+	if !valid || err != nil {
+		return -1, &InvalidPointerError{Pointer: p.String(), Reason: fmt.Sprintf("invalid array index: %s", token)}
+	}
+
+	if i >= length {
+		return -1, &IndexOutOfRangeError{Pointer: p[:index+1].String(), Index: i, Length: length}
+	}
+
+	return i, nil
+}
+
+func (p Pointer) notContainerError(node interface{}, index int) error {
+	return &TypeMismatchError{
+		Path:     p[:index].String(),
+		Index:    -1,
+		Expected: "OBJECT or ARRAY",
+		Actual:   fmt.Sprintf("%T", node),
+	}
+}
+
+func escapePointerToken(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+// withSnippet fills snippet of document into error produced by Pointer
+func (r Rmap) withSnippet(err error) error {
+	switch e := err.(type) {
+	case *KeyNotFoundError:
+		e.Snippet = errorSnippet(r)
+	case *TypeMismatchError:
+		e.Snippet = errorSnippet(r)
+	case *IndexOutOfRangeError:
+		e.Snippet = errorSnippet(r)
+	}
+
+	return err
+}
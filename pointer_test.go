@@ -0,0 +1,116 @@
+package rmap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePointer(t *testing.T) {
+	ptr, err := ParsePointer("/a~1b/m~0n/0")
+	assert.Nil(t, err)
+	assert.Equal(t, Pointer{"a/b", "m~n", "0"}, ptr)
+	assert.Equal(t, "/a~1b/m~0n/0", ptr.String())
+
+	ptr, err = ParsePointer("")
+	assert.Nil(t, err)
+	assert.Len(t, ptr, 0)
+
+	for _, invalid := range []string{"a", "/a~", "/a~2"} {
+		_, err = ParsePointer(invalid)
+		assert.True(t, errors.Is(err, ErrInvalidPointer), invalid)
+	}
+}
+
+func TestPointerGet(t *testing.T) {
+	doc := MustNewFromString(`{"a/b":{"arr":[1,{"c":"d"}]},"":"empty"}`)
+
+	val, err := MustParsePointer("/a~1b/arr/1/c").Get(doc.Mapa)
+	assert.Nil(t, err)
+	assert.Equal(t, "d", val)
+
+	val, err = MustParsePointer("/").Get(doc)
+	assert.Nil(t, err)
+	assert.Equal(t, "empty", val)
+
+	_, err = MustParsePointer("/a~1b/arr/2").Get(doc)
+	var outOfRange *IndexOutOfRangeError
+	assert.True(t, errors.As(err, &outOfRange))
+	assert.Equal(t, 2, outOfRange.Index)
+	assert.Equal(t, 2, outOfRange.Length)
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	_, err = MustParsePointer("/a~1b/arr/-").Get(doc)
+	assert.True(t, errors.Is(err, ErrIndexOutOfRange))
+
+	_, err = MustParsePointer("/a~1b/arr/01").Get(doc)
+	assert.True(t, errors.Is(err, ErrInvalidPointer))
+
+	_, err = MustParsePointer("/a~1b/missing").Get(doc)
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	assert.False(t, errors.Is(err, ErrIndexOutOfRange))
+
+	_, err = MustParsePointer("/missing/a/b").Get(doc)
+	var notFound *KeyNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "/missing/a/b", notFound.Path)
+	assert.Equal(t, "/missing", notFound.Missing)
+
+	_, err = MustParsePointer("/a~1b/arr/0/x").Get(doc)
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+}
+
+func TestPointerTypedContainers(t *testing.T) {
+	doc := NewFromMap(map[string]interface{}{
+		"rmaps":   []Rmap{NewFromMap(map[string]interface{}{"name": "first"})},
+		"strings": map[string]string{"key": "value"},
+		"list":    []string{"a"},
+	})
+
+	val, err := MustParsePointer("/rmaps/0/name").Get(doc)
+	assert.Nil(t, err)
+	assert.Equal(t, "first", val)
+
+	assert.Nil(t, MustParsePointer("/strings/new").Set(doc, "added"))
+	assert.Equal(t, "added", doc.Mapa["strings"].(map[string]string)["new"])
+
+	err = MustParsePointer("/strings/new").Set(doc, 1)
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+
+	assert.Nil(t, MustParsePointer("/rmaps/-").Set(doc, map[string]interface{}{"name": "second"}))
+	assert.Len(t, doc.Mapa["rmaps"], 2)
+	assert.Equal(t, "second", doc.MustGetJPtrString("/rmaps/1/name"))
+
+	assert.Nil(t, MustParsePointer("/list/0").Delete(doc))
+	assert.Equal(t, []string{}, doc.Mapa["list"])
+}
+
+func TestPointerSetDeleteExists(t *testing.T) {
+	doc := MustNewFromString(`{"arr":[1,2,3],"obj":{"key":"value"}}`)
+
+	assert.Nil(t, doc.SetJPtr("/arr/-", 4.0))
+	assert.Nil(t, doc.SetJPtr("/arr/0", 0.0))
+	assert.Nil(t, doc.DeleteJPtr("/arr/1"))
+	assert.Equal(t, []interface{}{0.0, 3.0, 4.0}, doc.Mapa["arr"])
+
+	err := doc.SetJPtr("/arr/5", 1)
+	assert.True(t, errors.Is(err, ErrIndexOutOfRange))
+
+	err = doc.DeleteJPtr("/obj/missing")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	assert.True(t, doc.MustExistsJPtr("/obj/key"))
+	assert.False(t, doc.MustExistsJPtr("/obj/missing"))
+	assert.False(t, doc.MustExistsJPtr("/arr/10"))
+
+	_, err = doc.ExistsJPtr("/obj/key/deeper")
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+}
+
+func TestSetJPtrRecursiveEscaped(t *testing.T) {
+	doc := NewEmpty()
+
+	assert.Nil(t, doc.SetJPtrRecursive("/a~1b/c", "value"))
+	assert.Equal(t, "value", doc.MustGetRmap("a/b").MustGetString("c"))
+}
@@ -0,0 +1,224 @@
+package rmap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactAction is what happens with value matched by RedactRule
+type RedactAction int
+
+const (
+	// RedactMask replaces value with RedactedValue
+	RedactMask RedactAction = iota
+	// RedactHash replaces value with "sha256:" and hex of SHA-256 of its canonical JSON, equal values stay correlatable
+	RedactHash
+	// RedactDrop removes value, array elements are removed, so indexes of following elements change
+	RedactDrop
+)
+
+// RedactedValue replaces values masked by RedactMask
+var RedactedValue = "[REDACTED]"
+
+// errorRedactionPolicy holds []compiledRedactRule set by SetErrorRedactionPolicy
+var errorRedactionPolicy atomic.Value
+
+// SetErrorRedactionPolicy sets rules applied to documents and values embedded in error messages returned by this package
+// Dropped values are masked in error values, nil disables redaction. It is safe to call concurrently with other functions
+func SetErrorRedactionPolicy(rules []RedactRule) {
+	errorRedactionPolicy.Store(compileRedactRules(rules))
+}
+
+// ErrorRedactionPolicy returns rules set by SetErrorRedactionPolicy
+func ErrorRedactionPolicy() []RedactRule {
+	compiled := loadErrorRedactionPolicy()
+	if len(compiled) == 0 {
+		return nil
+	}
+
+	rules := make([]RedactRule, 0, len(compiled))
+	for _, c := range compiled {
+		rules = append(rules, c.RedactRule)
+	}
+
+	return rules
+}
+
+func loadErrorRedactionPolicy() []compiledRedactRule {
+	compiled, _ := errorRedactionPolicy.Load().([]compiledRedactRule)
+	return compiled
+}
+
+// RedactRule matches values to be redacted
+// If more than one of Pointer, Key and Value is set, all of them must match, rule with none of them set matches nothing
+type RedactRule struct {
+	Pointer string         // exact JSONPointer of value
+	Key     string         // glob (* and ?) matched case-insensitively against name of object member
+	Value   *regexp.Regexp // matched against string values
+	Action  RedactAction
+}
+
+type compiledRedactRule struct {
+	RedactRule
+	key *regexp.Regexp
+}
+
+// Redact returns deep copy of Rmap with values matched by rules redacted, first matching rule is applied
+// Containers in returned Rmap are map[string]interface{} and []interface{}
+func (r Rmap) Redact(rules []RedactRule) Rmap {
+	return r.redact(compileRedactRules(rules))
+}
+
+func (r Rmap) redact(compiled []compiledRedactRule) Rmap {
+	out := make(map[string]interface{}, len(r.Mapa))
+	for key, value := range r.Mapa {
+		if redacted, keep := redactValue(compiled, Pointer{key}, key, true, value); keep {
+			out[key] = redacted
+		}
+	}
+
+	return NewFromMap(out)
+}
+
+func compileRedactRules(rules []RedactRule) []compiledRedactRule {
+	compiled := make([]compiledRedactRule, 0, len(rules))
+	for _, rule := range rules {
+		c := compiledRedactRule{RedactRule: rule}
+		if rule.Key != "" {
+			c.key = globToRegexp(rule.Key)
+		}
+
+		compiled = append(compiled, c)
+	}
+
+	return compiled
+}
+
+// redactValue returns redacted value and false, if it should be dropped
+func redactValue(rules []compiledRedactRule, ptr Pointer, key string, hasKey bool, value interface{}) (interface{}, bool) {
+	for _, rule := range rules {
+		if rule.matches(ptr, key, hasKey, value) {
+			return rule.apply(value)
+		}
+	}
+
+	if obj, ok := asObject(value); ok {
+		out := make(map[string]interface{}, len(obj))
+		for childKey, child := range obj {
+			if redacted, keep := redactValue(rules, ptr.Append(childKey), childKey, true, child); keep {
+				out[childKey] = redacted
+			}
+		}
+		return out, true
+	}
+
+	if arr, ok := asArray(value); ok {
+		out := make([]interface{}, 0, len(arr))
+		for index, child := range arr {
+			if redacted, keep := redactValue(rules, ptr.Append(strconv.Itoa(index)), "", false, child); keep {
+				out = append(out, redacted)
+			}
+		}
+		return out, true
+	}
+
+	return value, true
+}
+
+func (c compiledRedactRule) matches(ptr Pointer, key string, hasKey bool, value interface{}) bool {
+	if c.Pointer == "" && c.key == nil && c.Value == nil {
+		return false
+	}
+
+	if c.Pointer != "" && c.Pointer != ptr.String() {
+		return false
+	}
+
+	if c.key != nil && (!hasKey || !c.key.MatchString(key)) {
+		return false
+	}
+
+	if c.Value != nil {
+		s, isString := value.(string)
+		if !isString || !c.Value.MatchString(s) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c compiledRedactRule) apply(value interface{}) (interface{}, bool) {
+	switch c.Action {
+	case RedactDrop:
+		return nil, false
+	case RedactHash:
+		byt, err := CanonicalJSON(value)
+		if err != nil {
+			byt = []byte(fmt.Sprintf("%v", value))
+		}
+		sum := sha256.Sum256(byt)
+		return "sha256:" + hex.EncodeToString(sum[:]), true
+	default:
+		return RedactedValue, true
+	}
+}
+
+// globToRegexp converts glob with * and ? wildcards to case-insensitive regexp matching whole string
+func globToRegexp(glob string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("(?is)^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+
+	return regexp.MustCompile(sb.String())
+}
+
+// redactErrorValue formats value on path for error message, error redaction policy is applied
+func redactErrorValue(path string, value interface{}) string {
+	return fmt.Sprintf("%v", redactErrorInterface(path, value))
+}
+
+// redactErrorInterface returns value on key or JSONPointer path with error redaction policy applied, empty path is root
+func redactErrorInterface(path string, value interface{}) interface{} {
+	policy := loadErrorRedactionPolicy()
+	if len(policy) == 0 {
+		return value
+	}
+
+	ptr := Pointer{path}
+	switch {
+	case path == "":
+		ptr = Pointer{}
+	case strings.HasPrefix(path, "/"):
+		if parsed, err := ParsePointer(path); err == nil {
+			ptr = parsed
+		}
+	}
+
+	key, hasKey := "", len(ptr) > 0
+	if hasKey {
+		key = ptr[len(ptr)-1]
+	}
+
+	redacted, keep := redactValue(policy, ptr, key, hasKey, value)
+	if !keep {
+		return RedactedValue
+	}
+
+	return redacted
+}
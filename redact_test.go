@@ -0,0 +1,77 @@
+package rmap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	rm := MustNewFromString(`{"user":"john","userPassword":"secret","token":"abc","card":"4111-1111-1111-1111","nested":{"ssn":"123","list":["keep","4111-1111-1111-1111"]},"internal":{"a":1}}`)
+	original := rm.String()
+
+	redacted := rm.Redact([]RedactRule{
+		{Key: "*password*", Action: RedactMask},
+		{Key: "token", Action: RedactHash},
+		{Pointer: "/nested/ssn", Action: RedactMask},
+		{Pointer: "/internal", Action: RedactDrop},
+		{Value: regexp.MustCompile(`^\d{4}-\d{4}-\d{4}-\d{4}$`), Action: RedactDrop},
+	})
+
+	// SHA-256 of canonical JSON "abc", including quotes
+	sum := sha256.Sum256([]byte(`"abc"`))
+	assert.Equal(t, `{"nested":{"list":["keep"],"ssn":"[REDACTED]"},"token":"sha256:`+hex.EncodeToString(sum[:])+`","user":"john","userPassword":"[REDACTED]"}`, redacted.String())
+
+	// original is untouched
+	assert.Equal(t, original, rm.String())
+}
+
+func TestErrorRedactionPolicy(t *testing.T) {
+	defer SetErrorRedactionPolicy(nil)
+	SetErrorRedactionPolicy([]RedactRule{{Key: "*password*", Action: RedactMask}})
+
+	rm := MustNewFromString(`{"password":"secret","created":"not-a-time"}`)
+
+	_, err := rm.GetString("missing")
+	assert.NotContains(t, err.Error(), "secret")
+	assert.Contains(t, err.Error(), RedactedValue)
+
+	_, err = rm.GetInt("password")
+	assert.NotContains(t, err.Error(), "secret")
+
+	_, err = rm.GetTime("password")
+	assert.NotContains(t, err.Error(), "secret")
+
+	_, err = rm.GetTime("created")
+	assert.Contains(t, err.Error(), "not-a-time")
+}
+
+func TestErrorRedactionPolicyConcurrent(t *testing.T) {
+	defer SetErrorRedactionPolicy(nil)
+	rules := []RedactRule{{Key: "password"}}
+	rm := MustNewFromString(`{"password":"secret"}`)
+
+	wg := sync.WaitGroup{}
+	for index := 0; index < 10; index++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetErrorRedactionPolicy(rules)
+			SetErrorSnippetLength(ErrorSnippetLength())
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := rm.GetString("missing")
+			assert.NotNil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, rules, ErrorRedactionPolicy())
+	_, err := rm.GetString("missing")
+	assert.NotContains(t, err.Error(), "secret")
+}
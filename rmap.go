@@ -3,22 +3,18 @@ package rmap
 import (
     "bytes"
     "context"
-    "encoding/csv"
     "encoding/json"
     "fmt"
     "io"
     "io/ioutil"
     "os"
-    "sort"
     "strconv"
-    "strings"
     "time"
 
     jsonpatch "github.com/evanphx/json-patch"
     "github.com/pkg/errors"
     "github.com/qri-io/jsonschema"
     "github.com/shopspring/decimal"
-    jsonptr "github.com/xeipuuv/gojsonpointer"
     "golang.org/x/crypto/blake2b"
     "gopkg.in/yaml.v2"
 )
@@ -30,14 +26,6 @@ type Rmap struct {
     Mapa map[string]interface{}
 }
 
-const (
-    errInvalidConvert         = "key: %s (value: %s) cannot be converted to: %s"
-    errInvalidKeyType         = "key: %s is not of type: %s in object: %s, but: %T"
-    errInvalidArrayKeyType    = "key: %s, array index: %d is not of type: %s in object: %s, but: %T"
-    errInvalidJPtrType        = "JSONPointer: %s is not of type: %s in object: %s, but: %T"
-    errInvalidArrayMemberType = "key: %s containing array has invalid element type on index: %d, expected: %s, got: %T"
-)
-
 // ConvertSliceToMaps converts slice of []Rmap to []interface{} containing map[string]interface{}, so it can be marshalled
 func ConvertSliceToMaps(slice []Rmap) []interface{} {
     outputSlice := make([]interface{}, 0, len(slice))
@@ -49,7 +37,10 @@ func ConvertSliceToMaps(slice []Rmap) []interface{} {
     return outputSlice
 }
 
-func NewSliceFromCsv(csvF string) ([]Rmap, error) {
+// NewSliceFromCsv reads CSV file with header, every non-empty line is one Rmap with cells stored as strings under column names
+// WithArrayMode (and WithArrayDelimiter, WithFillValue) reverses RmapsToCSV with the same options, see NewSliceFromCSVReader for typed reading
+// Numbers and booleans are read as strings, cells equal to fill value are missing, so nulls written as empty cells are lost
+func NewSliceFromCsv(csvF string, opts ...CSVOption) ([]Rmap, error) {
     frdr, err := os.Open(csvF)
     if err != nil {
         return nil, err
@@ -57,44 +48,10 @@ func NewSliceFromCsv(csvF string) ([]Rmap, error) {
 
     defer func() { _ = frdr.Close() }()
 
-    r := csv.NewReader(frdr)
-
-    hdr, err := r.Read()
-    if err != nil {
-        return nil, err
-    }
-
-    out := []Rmap{}
-
-    for {
-        nextLine, err := r.Read()
-        if err != nil {
-            if err == io.EOF {
-                break
-            }
-            return nil, err
-        }
+    options := newCSVOptions(opts)
+    options.rawCells = !options.unflatten
 
-        isEmpty := true
-        for _, v := range nextLine {
-            if v != "" {
-                isEmpty = false
-                break
-            }
-        }
-
-        // only append non empty lines (contains at least one string value)
-        if !isEmpty {
-            nextRm := NewEmpty()
-            for i, hdrC := range hdr {
-                nextRm.Mapa[hdrC] = nextLine[i]
-            }
-
-            out = append(out, nextRm)
-        }
-    }
-
-    return out, nil
+    return readCSV(frdr, options)
 }
 
 func NewFromBytes(bytes []byte) (Rmap, error) {
@@ -254,11 +211,6 @@ func (r Rmap) BytesRef() *[]byte {
     return &byt
 }
 
-func (r Rmap) Copy() Rmap {
-    rm, _ := NewFromBytes(r.Bytes())
-    return rm
-}
-
 func (r Rmap) WrappedResult() Rmap {
     return NewFromMap(map[string]interface{}{
         "result": r.Mapa,
@@ -283,6 +235,7 @@ func (r Rmap) ValidateSchema(schema Rmap) error {
 }
 
 // ValidateSchemaBytes checks if Rmap satisfies JSONSchema (bytes form) in argument
+// Returned error is *ValidationError, if document is invalid
 func (r Rmap) ValidateSchemaBytes(schema []byte) error {
     // load schema
     rSchema := &jsonschema.Schema{}
@@ -290,29 +243,19 @@ func (r Rmap) ValidateSchemaBytes(schema []byte) error {
         return errors.Wrapf(err, "json.Unmarshal() failed")
     }
 
-    // if any errors are present, concat them into error
-    if errs, _ := rSchema.ValidateBytes(context.Background(), r.Bytes()); len(errs) > 0 {
-        errorStrings := make([]string, 0, len(errs))
-
-        for _, err := range errs {
-            errorStrings = append(errorStrings, fmt.Sprintf("InvalidValue: %+v, PropertyPath: %s, Message: %s", err.InvalidValue, err.PropertyPath, err.Message))
-        }
-        sort.Strings(errorStrings)
-        return errors.New(strings.Join(errorStrings, "\n"))
-    }
-
-    return nil
+    // if any errors are present, return them as *ValidationError
+    errs, _ := rSchema.ValidateBytes(context.Background(), r.Bytes())
+    return schemaErrors(errs, schemaMap(schema))
 }
 
 func (r Rmap) DeleteJPtr(jptr string) error {
-    ptr, err := jsonptr.NewJsonPointer(jptr)
+    ptr, err := ParsePointer(jptr)
     if err != nil {
-        return errors.Wrapf(err, "jsonptr.NewJsonPointer() failed")
+        return err
     }
 
-    _, err = ptr.Delete(r.Mapa)
-    if err != nil {
-        return errors.Wrapf(err, "ptr.Delete() failed")
+    if err := ptr.Delete(r.Mapa); err != nil {
+        return errors.Wrapf(r.withSnippet(err), "ptr.Delete() failed")
     }
 
     return nil
@@ -326,14 +269,14 @@ func (r Rmap) MustDeleteJPtr(jptr string) {
 
 // GetJPtr gets something from Rmap using JSONPointer, no type is asserted
 func (r Rmap) GetJPtr(path string) (interface{}, error) {
-    ptr, err := jsonptr.NewJsonPointer(path)
+    ptr, err := ParsePointer(path)
     if err != nil {
-        return nil, errors.Wrapf(err, "jsonptr.NewJsonPointer() failed")
+        return nil, err
     }
 
-    value, _, err := ptr.Get(r.Mapa)
+    value, err := ptr.Get(r.Mapa)
     if err != nil {
-        return nil, errors.Wrapf(err, "ptr.Get() failed")
+        return nil, r.withSnippet(err)
     }
 
     return value, nil
@@ -350,9 +293,9 @@ func (r Rmap) MustGetJPtr(path string) interface{} {
 
 // SetJPtr sets something in Rmap using JSONPointer
 func (r Rmap) SetJPtr(path string, value interface{}) error {
-    ptr, err := jsonptr.NewJsonPointer(path)
+    ptr, err := ParsePointer(path)
     if err != nil {
-        return errors.Wrapf(err, "jsonptr.NewJsonPointer() failed")
+        return err
     }
 
     rm, ok := value.(Rmap)
@@ -361,8 +304,8 @@ func (r Rmap) SetJPtr(path string, value interface{}) error {
         value = rm.Mapa
     }
 
-    if _, err := ptr.Set(r.Mapa, value); err != nil {
-        return errors.Wrapf(err, "ptr.Set() failed")
+    if err := ptr.Set(r.Mapa, value); err != nil {
+        return errors.Wrapf(r.withSnippet(err), "ptr.Set() failed")
     }
 
     return nil
@@ -370,26 +313,24 @@ func (r Rmap) SetJPtr(path string, value interface{}) error {
 
 // SetJPtrRecursive works like SetJPtr, but will create any missing parts of path
 func (r Rmap) SetJPtrRecursive(jptr string, value interface{}) error {
-    pathFields := strings.Split(jptr[1:], "/") // split jptr into all sub objects
+    ptr, err := ParsePointer(jptr)
+    if err != nil {
+        return err
+    }
 
-    for pathIndex, _ := range pathFields[:len(pathFields)-1] { // iterate until last elem (that will be set to supplied value, everything inbetween will be set to map if it doesnt exists)
-        subPathJPtr := "/" + strings.Join(pathFields[0:pathIndex+1], "/")
+    // iterate until last elem (that will be set to supplied value, everything inbetween will be set to map if it doesnt exists)
+    for pathIndex := 1; pathIndex < len(ptr); pathIndex++ {
+        subPtr := ptr[:pathIndex]
 
-        ptr, err := jsonptr.NewJsonPointer(subPathJPtr)
+        exists, err := subPtr.Exists(r.Mapa)
         if err != nil {
-            return err
+            return r.withSnippet(err)
         }
 
-        _, _, err = ptr.Get(r.Mapa)
-        if err != nil {
-            if !strings.HasPrefix(err.Error(), "Object has no key") {
-                // some other err
-                return err
-            }
-
+        if !exists {
             // some part of jptr does not exists, create it
-            if _, err := ptr.Set(r.Mapa, map[string]interface{}{}); err != nil {
-                return err
+            if err := subPtr.Set(r.Mapa, map[string]interface{}{}); err != nil {
+                return errors.Wrapf(r.withSnippet(err), "ptr.Set() failed")
             }
         }
     }
@@ -427,20 +368,17 @@ func (r Rmap) ExistsMany(keys []string) bool {
 
 // ExistsJPtr checks if some key (even nested), exists
 func (r Rmap) ExistsJPtr(path string) (bool, error) {
-    ptr, err := jsonptr.NewJsonPointer(path)
+    ptr, err := ParsePointer(path)
     if err != nil {
-        return false, errors.Wrapf(err, "jsonptr.NewJsonPointer() failed")
+        return false, err
     }
 
-    if _, _, err := ptr.Get(r.Mapa); err != nil {
-        // TODO this is not ideal, do not check error message, but no other API is available
-        if strings.HasPrefix(err.Error(), "Object has no key") {
-            return false, nil
-        }
-        return false, errors.Wrapf(err, "ptr.Get() failed")
+    exists, err := ptr.Exists(r.Mapa)
+    if err != nil {
+        return false, r.withSnippet(err)
     }
 
-    return true, nil
+    return exists, nil
 }
 
 func (r Rmap) MustExistsJPtr(path string) bool {
@@ -459,7 +397,7 @@ func (r Rmap) GetJPtrString(path string) (string, error) {
     }
     valS, ok := val.(string)
     if !ok {
-        return "", fmt.Errorf("JSONPointer path: %s is not a STRING in object: %s", path, r.String())
+        return "", newTypeMismatchError(r, path, -1, "STRING", val)
     }
     return valS, nil
 }
@@ -480,7 +418,7 @@ func (r Rmap) GetJPtrBool(path string) (bool, error) {
     }
     valB, ok := val.(bool)
     if !ok {
-        return false, fmt.Errorf("JSONPointer path: %s is not a BOOLEAN in object: %s", path, r.String())
+        return false, newTypeMismatchError(r, path, -1, "BOOLEAN", val)
     }
     return valB, nil
 }
@@ -500,15 +438,7 @@ func (r Rmap) GetJPtrInt(path string) (int, error) {
         return -1, errors.Wrapf(err, "r.GetJPtr() failed")
     }
 
-    // integers in JSON does not exist, it only knows float64, so those will be in something unmarshalled
-    switch val.(type) {
-    case float64:
-        return int(val.(float64)), nil
-    case int:
-        return val.(int), nil
-    default:
-        return -1, fmt.Errorf("JSONPointer path: %s is not an INT or FLOAT64 in object: %s, but: %T", path, r.String(), val)
-    }
+    return r.interfaceToInt(val, path)
 }
 
 func (r Rmap) MustGetJPtrInt(path string) int {
@@ -598,7 +528,7 @@ func (r Rmap) GetJPtrRmap(path string) (Rmap, error) {
     case Rmap:
         return valI.(Rmap), nil
     default:
-        return Rmap{}, fmt.Errorf(errInvalidJPtrType, path, "OBJECT", r.String(), valI)
+        return Rmap{}, newTypeMismatchError(r, path, -1, "OBJECT", valI)
     }
 }
 
@@ -618,7 +548,7 @@ func (r Rmap) GetJPtrIterable(path string) ([]interface{}, error) {
 
     valIterable, ok := valI.([]interface{})
     if !ok {
-        return []interface{}{}, fmt.Errorf(errInvalidJPtrType, path, "ARRAY", r.String(), valI)
+        return []interface{}{}, newTypeMismatchError(r, path, -1, "ARRAY", valI)
     }
 
     return valIterable, nil
@@ -641,7 +571,7 @@ func (r Rmap) GetJPtrTime(jptr string) (time.Time, error) {
 
     parsed, err := time.Parse(time.RFC3339, val)
     if err != nil {
-        return time.Time{}, err
+        return time.Time{}, newConversionError(jptr, val, "RFC3339 time", err)
     }
 
     return parsed, nil
@@ -662,12 +592,7 @@ func (r Rmap) GetJPtrFloat64(jptr string) (float64, error) {
         return -1.0, errors.Wrapf(err, "r.GetJPtr() failed")
     }
 
-    switch valI.(type) {
-    case float64:
-        return valI.(float64), nil
-    default:
-        return -1.0, fmt.Errorf(errInvalidJPtrType, jptr, "FLOAT64", r.String(), valI)
-    }
+    return r.interfaceToFloat64(valI, jptr)
 }
 
 func (r Rmap) MustGetJPtrFloat64(jptr string) float64 {
@@ -679,8 +604,25 @@ func (r Rmap) MustGetJPtrFloat64(jptr string) float64 {
     return val
 }
 
+// Hash returns BLAKE2b-256 hash of canonical form of Rmap (see CanonicalBytes()), use HashWith() for other algorithms
+// If Rmap cannot be canonicalized (NaN, Inf, unsupported types), hash of Bytes() is returned, use CanonicalHash() to get such error
 func (r Rmap) Hash() [32]byte {
-    return blake2b.Sum256(r.Bytes())
+    sum, err := r.CanonicalHash()
+    if err != nil {
+        return blake2b.Sum256(r.Bytes())
+    }
+
+    return sum
+}
+
+// CanonicalHash returns BLAKE2b-256 hash of canonical form of Rmap, error is returned if Rmap cannot be canonicalized
+func (r Rmap) CanonicalHash() ([32]byte, error) {
+    byt, err := r.CanonicalBytes()
+    if err != nil {
+        return [32]byte{}, err
+    }
+
+    return blake2b.Sum256(byt), nil
 }
 
 // Inject puts keys from value into this Rmap in path
@@ -755,7 +697,7 @@ func (r Rmap) MarshalJSON() ([]byte, error) {
 }
 
 func (r Rmap) YAMLBytes() ([]byte, error) {
-    return yaml.Marshal(r.Mapa)
+    return yaml.Marshal(yamlNumbers(r.Mapa))
 }
 
 func (r Rmap) MustYAMLBytes() []byte {
@@ -796,7 +738,7 @@ func (r Rmap) Get(key string) (interface{}, error) {
     if val, exists := r.Mapa[key]; exists {
         return val, nil
     }
-    return nil, fmt.Errorf("key: %s does not exist in object: %s", key, r.String())
+    return nil, newKeyNotFoundError(r, key)
 }
 
 func (r Rmap) GetBool(key string) (bool, error) {
@@ -807,7 +749,7 @@ func (r Rmap) GetBool(key string) (bool, error) {
 
     valB, ok := valI.(bool)
     if !ok {
-        return false, fmt.Errorf(errInvalidKeyType, key, "BOOLEAN", r.String(), valI)
+        return false, newTypeMismatchError(r, key, -1, "BOOLEAN", valI)
     }
     return valB, nil
 }
@@ -826,11 +768,22 @@ func (r Rmap) GetFloat64(key string) (float64, error) {
         return -1.0, errors.Wrap(err, "r.Get() failed")
     }
 
-    valF, ok := valI.(float64)
-    if !ok {
-        return -1.0, fmt.Errorf(errInvalidKeyType, key, "FLOAT64", r.String(), valI)
+    return r.interfaceToFloat64(valI, key)
+}
+
+func (r Rmap) interfaceToFloat64(valI interface{}, key string) (float64, error) {
+    switch v := valI.(type) {
+    case float64:
+        return v, nil
+    case json.Number:
+        valF, err := v.Float64()
+        if err != nil {
+            return -1.0, newConversionError(key, v.String(), "float64", err)
+        }
+        return valF, nil
+    default:
+        return -1.0, newTypeMismatchError(r, key, -1, "FLOAT64", valI)
     }
-    return valF, nil
 }
 
 func (r Rmap) MustGetFloat64(key string) float64 {
@@ -849,7 +802,7 @@ func (r Rmap) ConvertToInt(key string) (int, error) {
 
     val, err := strconv.Atoi(valS)
     if err != nil {
-        return -1, fmt.Errorf(errInvalidConvert, key, valS, "int")
+        return -1, newConversionError(key, valS, "int", err)
     }
 
     return val, nil
@@ -870,15 +823,27 @@ func (r Rmap) GetInt(key string) (int, error) {
         return -1, errors.Wrap(err, "r.Get() failed")
     }
 
-    switch valI.(type) {
+    return r.interfaceToInt(valI, key)
+}
+
+func (r Rmap) interfaceToInt(valI interface{}, key string) (int, error) {
+    // integers in JSON does not exist, it only knows float64, so those will be in something unmarshalled
+    switch v := valI.(type) {
     case float64:
-        return int(valI.(float64)), nil
+        return int(v), nil
     case int64:
-        return int(valI.(int64)), nil
+        return int(v), nil
     case int:
-        return valI.(int), nil
+        return v, nil
+    case json.Number:
+        // decoded with UseNumber(), do not lose anything silently
+        valInt, err := numberToInt(v)
+        if err != nil {
+            return -1, newConversionError(key, v.String(), "int", err)
+        }
+        return valInt, nil
     default:
-        return -1, fmt.Errorf(errInvalidKeyType, key, "INT or FLOAT64", r.String(), valI)
+        return -1, newTypeMismatchError(r, key, -1, "INT or FLOAT64", valI)
     }
 }
 
@@ -927,7 +892,7 @@ func (r Rmap) interfaceToIterable(valI interface{}, key string) ([]interface{},
             valIter = append(valIter, xI)
         }
     default:
-        return nil, fmt.Errorf(errInvalidKeyType, key, "ARRAY", r.String(), valI)
+        return nil, newTypeMismatchError(r, key, -1, "ARRAY", valI)
     }
 
     return valIter, nil
@@ -948,7 +913,7 @@ func (r Rmap) iterableToString(iter []interface{}, key string) ([]string, error)
     for index, valI := range iter {
         valS, ok := valI.(string)
         if !ok {
-            return nil, fmt.Errorf(errInvalidArrayKeyType, key, index, "String", r.String(), valI)
+            return nil, newTypeMismatchError(r, key, index, "STRING", valI)
         }
 
         output[index] = valS
@@ -963,7 +928,7 @@ func (r Rmap) iterableToRmap(iter []interface{}, key string) ([]Rmap, error) {
     for index, subObj := range iter {
         subMap, ok := subObj.(map[string]interface{})
         if !ok {
-            return nil, fmt.Errorf(errInvalidArrayKeyType, key, index, "OBJECT", r.String(), subObj)
+            return nil, newTypeMismatchError(r, key, index, "OBJECT", subObj)
         }
 
         output[index] = NewFromMap(subMap)
@@ -1064,7 +1029,7 @@ func (r Rmap) GetRmap(key string) (Rmap, error) {
     case Rmap:
         return valI.(Rmap), nil
     default:
-        return Rmap{}, fmt.Errorf(errInvalidKeyType, key, "OBJECT", r.String(), valI)
+        return Rmap{}, newTypeMismatchError(r, key, -1, "OBJECT", valI)
     }
 }
 
@@ -1084,7 +1049,7 @@ func (r Rmap) GetString(key string) (string, error) {
 
     valS, ok := valI.(string)
     if !ok {
-        return "", fmt.Errorf(errInvalidKeyType, key, "STRING", r.String(), valI)
+        return "", newTypeMismatchError(r, key, -1, "STRING", valI)
     }
     return valS, nil
 }
@@ -1113,7 +1078,7 @@ func (r Rmap) GetTime(key string) (time.Time, error) {
 
     parsed, err := time.ParseInLocation(time.RFC3339, valS, time.UTC)
     if err != nil {
-        return time.Time{}, errors.Wrap(err, "time.Parse() failed")
+        return time.Time{}, newConversionError(key, valS, "RFC3339 time", err)
     }
 
     return parsed, nil
@@ -1128,14 +1093,38 @@ func (r Rmap) MustGetTime(key string) time.Time {
 }
 
 func (r Rmap) GetDecimal(key string) (decimal.Decimal, error) {
-    valS, err := r.GetString(key)
+    valI, err := r.Get(key)
     if err != nil {
-        return decimal.Zero, errors.Wrap(err, "r.GetString() failed")
+        return decimal.Zero, errors.Wrap(err, "r.Get() failed")
+    }
+
+    return r.interfaceToDecimal(valI, key)
+}
+
+// interfaceToDecimal accepts string, json.Number (decoded with UseNumber()) or Go number (float64 from default decoding)
+func (r Rmap) interfaceToDecimal(valI interface{}, key string) (decimal.Decimal, error) {
+    var valS string
+
+    switch v := valI.(type) {
+    case string:
+        valS = v
+    case json.Number:
+        valS = v.String()
+    default:
+        if val, ok := asDecimal(valI); ok {
+            return val, nil
+        }
+
+        if _, ok := asNumber(valI); ok {
+            return decimal.Zero, newConversionError(key, valI, "decimal", errors.New("value is not finite"))
+        }
+
+        return decimal.Zero, newTypeMismatchError(r, key, -1, "STRING or NUMBER", valI)
     }
 
     val, err := decimal.NewFromString(valS)
     if err != nil {
-        return decimal.Zero, errors.Wrap(err, "decimal.NewFromString() failed")
+        return decimal.Zero, newConversionError(key, valS, "decimal", err)
     }
 
     return val, nil
@@ -1151,17 +1140,12 @@ func (r Rmap) MustGetDecimal(key string) decimal.Decimal {
 }
 
 func (r Rmap) GetJPtrDecimal(jptr string) (decimal.Decimal, error) {
-    valS, err := r.GetJPtrString(jptr)
-    if err != nil {
-        return decimal.Zero, errors.Wrap(err, "r.GetJPtrString() failed")
-    }
-
-    val, err := decimal.NewFromString(valS)
+    valI, err := r.GetJPtr(jptr)
     if err != nil {
-        return decimal.Zero, errors.Wrap(err, "decimal.NewFromString() failed")
+        return decimal.Zero, errors.Wrap(err, "r.GetJPtr() failed")
     }
 
-    return val, nil
+    return r.interfaceToDecimal(valI, jptr)
 }
 
 func (r Rmap) MustGetJPtrDecimal(jptr string) decimal.Decimal {
@@ -3,7 +3,7 @@ package rmap
 import (
     "bytes"
     "encoding/json"
-    "log"
+    "errors"
     "testing"
     "time"
 
@@ -418,7 +418,8 @@ func TestSetJPtrRecursiveBadSubjObj(t *testing.T) {
 
     err := obj.SetJPtrRecursive(jptr, value)
     assert.NotNil(t, err)
-    assert.Equal(t, "ptr.Set() failed: Invalid token reference 'obj'", err.Error())
+    assert.True(t, errors.Is(err, ErrTypeMismatch))
+    assert.Equal(t, `ptr.Set() failed: JSONPointer: /very/deep is not of type: OBJECT or ARRAY in object: {"very":{"deep":"not_an_object"}}, but: string`, err.Error())
 }
 
 func TestReferences(t *testing.T) {
@@ -0,0 +1,191 @@
+package rmap
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// JSON Schema keywords, which contain subschemas
+var (
+	// keyword: {name: subschema}
+	schemaMapKeywords = map[string]bool{"properties": true, "patternProperties": true, "definitions": true, "$defs": true, "dependentSchemas": true}
+	// keyword: subschema
+	schemaSingleKeywords = map[string]bool{"additionalProperties": true, "additionalItems": true, "contains": true, "propertyNames": true, "not": true, "if": true, "then": true, "else": true, "unevaluatedItems": true, "unevaluatedProperties": true}
+	// keyword: [subschema, ...]
+	schemaArrayKeywords = map[string]bool{"allOf": true, "anyOf": true, "oneOf": true}
+)
+
+// subschemas calls fn for every direct subschema of schema, tokens are relative JSONPointer of subschema
+// Keywords are iterated in sorted order, so traversal is deterministic
+func subschemas(schema map[string]interface{}, fn func(tokens []string, sub map[string]interface{})) {
+	keywords := make([]string, 0, len(schema))
+	for keyword := range schema {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+
+	for _, keyword := range keywords {
+		value := schema[keyword]
+
+		switch {
+		case schemaMapKeywords[keyword], keyword == "dependencies":
+			obj, ok := asObject(value)
+			if !ok {
+				continue
+			}
+
+			names := make([]string, 0, len(obj))
+			for name := range obj {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				// dependencies can also contain array of required property names
+				if sub, ok := asObject(obj[name]); ok {
+					fn([]string{keyword, name}, sub)
+				}
+			}
+		case schemaSingleKeywords[keyword]:
+			if sub, ok := asObject(value); ok {
+				fn([]string{keyword}, sub)
+			}
+		case schemaArrayKeywords[keyword], keyword == "items":
+			// items can be single subschema or array of subschemas (tuple validation)
+			if sub, ok := asObject(value); ok {
+				fn([]string{keyword}, sub)
+				continue
+			}
+
+			arr, _ := asArray(value)
+			for index, elem := range arr {
+				if sub, ok := asObject(elem); ok {
+					fn([]string{keyword, strconv.Itoa(index)}, sub)
+				}
+			}
+		}
+	}
+}
+
+// walkSchema calls fn for schema and all its subschemas recursively (pre-order)
+func walkSchema(schema map[string]interface{}, ptr Pointer, fn func(ptr Pointer, schema map[string]interface{})) {
+	fn(ptr, schema)
+
+	subschemas(schema, func(tokens []string, sub map[string]interface{}) {
+		walkSchema(sub, ptr.Append(tokens...), fn)
+	})
+}
+
+// schemaTransform returns transformed value and true, or unchanged value and false
+// It must not modify value in-place
+type schemaTransform func(value interface{}, schema map[string]interface{}) (interface{}, bool)
+
+// applySchema calls fn for value and then recursively for its members with their subschemas
+// Local $refs are followed and allOf subschemas are applied one by one, anyOf, oneOf, not and conditionals are ignored,
+// because it is not known which of them applies. Objects and arrays are copied only if some member changed
+func applySchema(root, schema map[string]interface{}, value interface{}, fn schemaTransform) (interface{}, bool) {
+	_, schema, ok := schemaLocator{root: root}.resolve(Pointer{}, schema)
+	if !ok {
+		return value, false
+	}
+
+	value, changed := fn(value, schema)
+
+	if obj, isObj := asObject(value); isObj {
+		var out map[string]interface{}
+		for key, member := range obj {
+			sub, ok := memberSchema(schema, key, false)
+			if !ok {
+				continue
+			}
+
+			if transformed, memberChanged := applySchema(root, sub, member, fn); memberChanged {
+				if out == nil {
+					out = shallowCopyObject(obj)
+				}
+				out[key] = transformed
+			}
+		}
+
+		if out != nil {
+			value, changed = out, true
+		}
+	} else if arr, isArr := asArray(value); isArr {
+		var out []interface{}
+		for index, elem := range arr {
+			sub, ok := memberSchema(schema, strconv.Itoa(index), true)
+			if !ok {
+				continue
+			}
+
+			if transformed, elemChanged := applySchema(root, sub, elem, fn); elemChanged {
+				if out == nil {
+					out = append([]interface{}{}, arr...)
+				}
+				out[index] = transformed
+			}
+		}
+
+		if out != nil {
+			value, changed = out, true
+		}
+	}
+
+	branches, _ := asArray(schema["allOf"])
+	for _, branch := range branches {
+		if sub, ok := asObject(branch); ok {
+			if transformed, branchChanged := applySchema(root, sub, value, fn); branchChanged {
+				value, changed = transformed, true
+			}
+		}
+	}
+
+	return value, changed
+}
+
+// memberSchema returns subschema of member token of object, or of array, if array is true
+func memberSchema(schema map[string]interface{}, token string, array bool) (map[string]interface{}, bool) {
+	if array {
+		if sub, ok := asObject(schema["items"]); ok {
+			return sub, true
+		}
+
+		tuple, _ := asArray(schema["items"])
+		if index, err := strconv.Atoi(token); err == nil && index < len(tuple) {
+			return asObject(tuple[index])
+		}
+
+		return asObject(schema["additionalItems"])
+	}
+
+	if props, ok := asObject(schema["properties"]); ok {
+		if sub, ok := asObject(props[token]); ok {
+			return sub, true
+		}
+	}
+
+	if patterns, ok := asObject(schema["patternProperties"]); ok {
+		names := make([]string, 0, len(patterns))
+		for name := range patterns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if matched, err := regexp.MatchString(name, token); err == nil && matched {
+				return asObject(patterns[name])
+			}
+		}
+	}
+
+	return asObject(schema["additionalProperties"])
+}
+
+func shallowCopyObject(obj map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(obj))
+	for key, value := range obj {
+		out[key] = value
+	}
+	return out
+}
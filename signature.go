@@ -0,0 +1,224 @@
+package rmap
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// JWSAlgorithm is value of "alg" header for Ed25519 signatures (RFC 8037)
+const JWSAlgorithm = "EdDSA"
+
+// SignOption configures Sign and Verify, both must be called with the same options
+type SignOption func(*signOptions)
+
+type signOptions struct {
+	detached bool
+	embed    string
+	exclude  []string
+	keyID    string
+}
+
+// Detached produces JWS with empty payload part (RFC 7515, Appendix F), document itself is the payload
+func Detached() SignOption {
+	return func(o *signOptions) {
+		o.detached = true
+	}
+}
+
+// EmbedSignature stores detached JWS into document on JSONPointer jptr
+// Value on jptr is always excluded from signed payload, Verify reads JWS from jptr if it is called with empty jws
+func EmbedSignature(jptr string) SignOption {
+	return func(o *signOptions) {
+		o.detached = true
+		o.embed = jptr
+	}
+}
+
+// ExcludeFromPayload removes values on JSONPointers from signed payload, so they can change without invalidating signature
+func ExcludeFromPayload(jptrs ...string) SignOption {
+	return func(o *signOptions) {
+		o.exclude = append(o.exclude, jptrs...)
+	}
+}
+
+// WithKeyID puts "kid" into JWS protected header
+func WithKeyID(kid string) SignOption {
+	return func(o *signOptions) {
+		o.keyID = kid
+	}
+}
+
+// Sign returns compact JWS signed by Ed25519 key over canonical form of Rmap (see CanonicalBytes())
+func (r Rmap) Sign(key ed25519.PrivateKey, opts ...SignOption) (string, error) {
+	options := newSignOptions(opts)
+
+	if len(key) != ed25519.PrivateKeySize {
+		return "", errors.Errorf("invalid Ed25519 private key length: %d", len(key))
+	}
+
+	payload, err := r.signingPayload(options)
+	if err != nil {
+		return "", err
+	}
+
+	header := map[string]interface{}{"alg": JWSAlgorithm}
+	if options.keyID != "" {
+		header["kid"] = options.keyID
+	}
+
+	headerBytes, err := CanonicalJSON(header)
+	if err != nil {
+		return "", err
+	}
+
+	encodedHeader := base64.RawURLEncoding.EncodeToString(headerBytes)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(key, []byte(encodedHeader+"."+encodedPayload))
+
+	if options.detached {
+		encodedPayload = ""
+	}
+
+	jws := encodedHeader + "." + encodedPayload + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+	if options.embed != "" {
+		if err := r.SetJPtr(options.embed, jws); err != nil {
+			return "", errors.Wrapf(err, "r.SetJPtr() failed")
+		}
+	}
+
+	return jws, nil
+}
+
+func (r Rmap) MustSign(key ed25519.PrivateKey, opts ...SignOption) string {
+	jws, err := r.Sign(key, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return jws
+}
+
+// Verify checks that jws is valid Ed25519 signature of this Rmap made by private key of pubkey
+// Both compact and detached JWS is accepted, payload of compact JWS must match canonical form of Rmap
+// If jws is empty, it is read from location set by EmbedSignature()
+func (r Rmap) Verify(pubkey ed25519.PublicKey, jws string, opts ...SignOption) error {
+	options := newSignOptions(opts)
+
+	if len(pubkey) != ed25519.PublicKeySize {
+		return errors.Errorf("invalid Ed25519 public key length: %d", len(pubkey))
+	}
+
+	if jws == "" {
+		if options.embed == "" {
+			return errors.Wrap(ErrInvalidSignature, "no JWS to verify")
+		}
+
+		embedded, err := r.GetJPtrString(options.embed)
+		if err != nil {
+			return errors.Wrapf(ErrInvalidSignature, "embedded JWS: %v", err)
+		}
+		jws = embedded
+	}
+
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 {
+		return errors.Wrapf(ErrInvalidSignature, "JWS must have 3 parts, got: %d", len(parts))
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return errors.Wrapf(ErrInvalidSignature, "header: %v", err)
+	}
+
+	header := struct {
+		Alg  string   `json:"alg"`
+		Crit []string `json:"crit"`
+	}{}
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return errors.Wrapf(ErrInvalidSignature, "header: %v", err)
+	}
+
+	if header.Alg != JWSAlgorithm {
+		return errors.Wrapf(ErrInvalidSignature, "unsupported algorithm: %s", header.Alg)
+	}
+
+	if len(header.Crit) > 0 {
+		return errors.Wrapf(ErrInvalidSignature, "unsupported critical header parameters: %v", header.Crit)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errors.Wrapf(ErrInvalidSignature, "signature: %v", err)
+	}
+
+	payload, err := r.signingPayload(options)
+	if err != nil {
+		return err
+	}
+
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	if parts[1] != "" {
+		attached, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return errors.Wrapf(ErrInvalidSignature, "payload: %v", err)
+		}
+
+		if !bytes.Equal(attached, payload) {
+			return errors.Wrap(ErrInvalidSignature, "JWS payload does not match document")
+		}
+		encodedPayload = parts[1]
+	}
+
+	if !ed25519.Verify(pubkey, []byte(parts[0]+"."+encodedPayload), signature) {
+		return errors.Wrap(ErrInvalidSignature, "signature does not match")
+	}
+
+	return nil
+}
+
+func newSignOptions(opts []SignOption) signOptions {
+	options := signOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+// signingPayload returns canonical form of Rmap without excluded and embedded values
+func (r Rmap) signingPayload(options signOptions) ([]byte, error) {
+	excluded := append([]string{}, options.exclude...)
+	if options.embed != "" {
+		excluded = append(excluded, options.embed)
+	}
+
+	doc := r
+	if len(excluded) > 0 {
+		doc = r.Copy()
+		for _, jptr := range excluded {
+			exists, err := doc.ExistsJPtr(jptr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "doc.ExistsJPtr() failed")
+			}
+
+			if exists {
+				if err := doc.DeleteJPtr(jptr); err != nil {
+					return nil, errors.Wrapf(err, "doc.DeleteJPtr() failed")
+				}
+			}
+		}
+	}
+
+	payload, err := doc.CanonicalBytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "doc.CanonicalBytes() failed")
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,66 @@
+package rmap
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKeys(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.Nil(t, err)
+	return pub, priv
+}
+
+func TestSignVerifyCompact(t *testing.T) {
+	pub, priv := testKeys(t)
+	rm := MustNewFromString(`{"asset":"car","price":1.50}`)
+
+	jws := rm.MustSign(priv, WithKeyID("key-1"))
+	assert.Len(t, strings.Split(jws, "."), 3)
+	assert.Nil(t, rm.Verify(pub, jws))
+
+	// logically same document loaded differently verifies too
+	assert.Nil(t, MustNewFromBytesWithOptions([]byte(`{"price":1.5,"asset":"car"}`), UseNumber()).Verify(pub, jws))
+
+	err := MustNewFromString(`{"asset":"bike","price":1.50}`).Verify(pub, jws)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	otherPub, _ := testKeys(t)
+	assert.True(t, errors.Is(rm.Verify(otherPub, jws), ErrInvalidSignature))
+	assert.True(t, errors.Is(rm.Verify(pub, "garbage"), ErrInvalidSignature))
+}
+
+func TestSignVerifyDetached(t *testing.T) {
+	pub, priv := testKeys(t)
+	rm := MustNewFromString(`{"asset":"car"}`)
+
+	jws := rm.MustSign(priv, Detached())
+	assert.Equal(t, "", strings.Split(jws, ".")[1])
+	assert.Nil(t, rm.Verify(pub, jws))
+
+	assert.Nil(t, rm.SetJPtr("/asset", "bike"))
+	assert.True(t, errors.Is(rm.Verify(pub, jws), ErrInvalidSignature))
+}
+
+func TestSignVerifyEmbedded(t *testing.T) {
+	pub, priv := testKeys(t)
+	rm := MustNewFromString(`{"asset":"car","meta":{"updated":"today"}}`)
+	opts := []SignOption{EmbedSignature("/signature"), ExcludeFromPayload("/meta/updated")}
+
+	jws := rm.MustSign(priv, opts...)
+	assert.Equal(t, jws, rm.MustGetString("signature"))
+	assert.Nil(t, rm.Verify(pub, "", opts...))
+
+	// excluded value can change
+	assert.Nil(t, rm.SetJPtr("/meta/updated", "tomorrow"))
+	assert.Nil(t, rm.Verify(pub, "", opts...))
+
+	assert.Nil(t, rm.SetJPtr("/asset", "bike"))
+	assert.True(t, errors.Is(rm.Verify(pub, "", opts...), ErrInvalidSignature))
+
+	assert.True(t, errors.Is(NewEmpty().Verify(pub, "", opts...), ErrInvalidSignature))
+}
@@ -0,0 +1,641 @@
+package rmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// StructOption configures Decode
+type StructOption func(*structOptions)
+
+type structOptions struct {
+	errorUnused bool
+}
+
+// ErrorUnused makes Decode fail, if object has key not mapped to any struct field
+func ErrorUnused() StructOption {
+	return func(o *structOptions) {
+		o.errorUnused = true
+	}
+}
+
+// FieldError is failure to decode one struct field
+type FieldError struct {
+	Field   string // Go path of field, for example Spec.Ports[1].Port
+	Pointer string // JSONPointer of value in Rmap
+	Err     error  // *TypeMismatchError, *ConversionError, *KeyNotFoundError for missing required field or other error
+}
+
+func (e *FieldError) Error() string {
+	field := e.Field
+	if field == "" {
+		field = "(root)"
+	}
+
+	return fmt.Sprintf("field: %s: %v", field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned by Decode, it contains errors of all fields, which cannot be decoded
+// errors.Is() and errors.As() match any of field errors
+type DecodeError struct {
+	Errors []*FieldError
+}
+
+func (e *DecodeError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Sprintf("cannot decode %d field(s): %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *DecodeError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// Decode stores Rmap into value pointed to by dst, usually a struct
+// Field name is taken from json tag or from Go name, rmap tag is "/source/pointer,required,omitempty,default=value":
+//   - source JSONPointer is relative to object decoded into struct, it overrides json name
+//   - required fails, if value is missing or null
+//   - default value is JSON literal or plain string, it is used if value is missing, it must be the last option
+//
+// Values are converted like in Get[T], so numbers are checked for overflow and strings are parsed to decimal.Decimal and RFC3339 time.Time
+// All fields are decoded, failures are returned together as *DecodeError
+func (r Rmap) Decode(dst interface{}, opts ...StructOption) error {
+	options := structOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.Errorf("Decode() requires non-nil pointer, got: %T", dst)
+	}
+
+	decoder := &structDecoder{options: options}
+	decoder.decode("", Pointer{}, r.Mapa, rv.Elem())
+	if len(decoder.errs) > 0 {
+		return &DecodeError{Errors: decoder.errs}
+	}
+
+	return nil
+}
+
+func (r Rmap) MustDecode(dst interface{}, opts ...StructOption) {
+	if err := r.Decode(dst, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// NewFromStruct creates Rmap from struct (or pointer to it), tags are the same as in Decode
+// Fields with omitempty in json or rmap tag are skipped, if they have zero value or are empty slices or maps
+// decimal.Decimal is stored as json.Number, so it is serialized exactly, time.Time as RFC3339 string
+func NewFromStruct(v interface{}) (Rmap, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return Rmap{}, errors.Errorf("NewFromStruct() requires struct, got: %T", v)
+	}
+
+	encoded, err := encodeValue("", rv)
+	if err != nil {
+		return Rmap{}, err
+	}
+
+	obj, _ := asObject(encoded)
+	return NewFromMap(obj), nil
+}
+
+func MustNewFromStruct(v interface{}) Rmap {
+	rm, err := NewFromStruct(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return rm
+}
+
+// structField is exported field of struct or of embedded struct
+type structField struct {
+	index      []int
+	name       string
+	ptr        Pointer
+	required   bool
+	omitEmpty  bool
+	hasDefault bool
+	def        interface{}
+}
+
+// structFields returns exported fields of struct type t, fields of embedded structs without json name are promoted
+func structFields(t reflect.Type) ([]structField, error) {
+	fields := []structField{}
+
+	for index := 0; index < t.NumField(); index++ {
+		sf := t.Field(index)
+		// exported fields of unexported embedded struct are accessible, unless it is a pointer
+		if sf.PkgPath != "" && !(sf.Anonymous && sf.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		jsonTag := sf.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+
+		jsonName, jsonOpts, _ := strings.Cut(jsonTag, ",")
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if sf.Anonymous && jsonName == "" && sf.Tag.Get("rmap") == "" && ft.Kind() == reflect.Struct {
+			embedded, err := structFields(ft)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, field := range embedded {
+				field.index = append([]int{index}, field.index...)
+				fields = append(fields, field)
+			}
+			continue
+		}
+
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		field := structField{index: []int{index}, name: sf.Name, omitEmpty: strings.Contains(","+jsonOpts+",", ",omitempty,")}
+		if jsonName == "" {
+			jsonName = sf.Name
+		}
+		field.ptr = Pointer{jsonName}
+
+		if err := field.parseTag(sf.Tag.Get("rmap")); err != nil {
+			return nil, errors.Wrapf(err, "field: %s has invalid rmap tag", sf.Name)
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func (f *structField) parseTag(tag string) error {
+	if tag == "" {
+		return nil
+	}
+
+	source, rest, _ := strings.Cut(tag, ",")
+	if source != "" {
+		ptr, err := ParsePointer(source)
+		if err != nil {
+			return err
+		}
+
+		if len(ptr) == 0 {
+			return errors.New("source JSONPointer cannot reference whole object")
+		}
+		f.ptr = ptr
+	}
+
+	for rest != "" {
+		if strings.HasPrefix(rest, "default=") {
+			literal := strings.TrimPrefix(rest, "default=")
+			f.hasDefault = true
+			if err := json.Unmarshal([]byte(literal), &f.def); err != nil {
+				f.def = literal
+			}
+			return nil
+		}
+
+		var option string
+		option, rest, _ = strings.Cut(rest, ",")
+		switch option {
+		case "required":
+			f.required = true
+		case "omitempty":
+			f.omitEmpty = true
+		default:
+			return errors.Errorf("unknown option: %s", option)
+		}
+	}
+
+	return nil
+}
+
+// fieldByIndex returns field of struct rv, nil embedded pointers are allocated if alloc is true, otherwise false is returned
+func fieldByIndex(rv reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for position, fieldIndex := range index {
+		if position > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !alloc || !rv.CanSet() {
+					return reflect.Value{}, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(fieldIndex)
+	}
+
+	return rv, true
+}
+
+type structDecoder struct {
+	options structOptions
+	errs    []*FieldError
+}
+
+func (d *structDecoder) fail(field string, ptr Pointer, err error) {
+	d.errs = append(d.errs, &FieldError{Field: field, Pointer: ptr.String(), Err: err})
+}
+
+func (d *structDecoder) mismatch(field string, ptr Pointer, rv reflect.Value, value interface{}) {
+	d.fail(field, ptr, &TypeMismatchError{Path: ptr.String(), Index: -1, Expected: rv.Type().String(), Actual: fmt.Sprintf("%T", value)})
+}
+
+func (d *structDecoder) decode(field string, ptr Pointer, value interface{}, rv reflect.Value) {
+	if value == nil {
+		switch rv.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+		return
+	}
+
+	switch {
+	case rv.Kind() == reflect.Interface && rv.NumMethod() == 0:
+		rv.Set(reflect.ValueOf(DeepCopy(value)))
+		return
+	case rv.Kind() == reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		d.decode(field, ptr, value, rv.Elem())
+		return
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		d.decodeMap(field, ptr, value, rv)
+		return
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8, rv.Kind() == reflect.Array:
+		d.decodeSlice(field, ptr, value, rv)
+		return
+	}
+
+	if converted, handled, err := convertReflect(value, rv.Type()); handled {
+		switch {
+		case err == ErrTypeMismatch:
+			d.mismatch(field, ptr, rv, value)
+		case err != nil:
+			d.fail(field, ptr, newConversionError(ptr.String(), value, rv.Type().String(), err))
+		default:
+			rv.Set(converted)
+		}
+		return
+	}
+
+	if rv.Kind() == reflect.Struct && !reflect.PointerTo(rv.Type()).Implements(jsonUnmarshalerType) {
+		d.decodeStruct(field, ptr, value, rv)
+		return
+	}
+
+	// other types (json.Unmarshaler, []byte) are decoded from JSON
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, rv.Addr().Interface())
+	}
+	if err != nil {
+		d.fail(field, ptr, newConversionError(ptr.String(), value, rv.Type().String(), err))
+	}
+}
+
+func (d *structDecoder) decodeStruct(field string, ptr Pointer, value interface{}, rv reflect.Value) {
+	obj, ok := asObject(value)
+	if !ok {
+		d.mismatch(field, ptr, rv, value)
+		return
+	}
+
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		d.fail(field, ptr, err)
+		return
+	}
+
+	used := map[string]bool{}
+	for _, f := range fields {
+		fieldPath := f.name
+		if field != "" {
+			fieldPath = field + "." + f.name
+		}
+		memberPtr := ptr.Append(f.ptr...)
+		used[f.ptr[0]] = true
+
+		exists, err := f.ptr.Exists(obj)
+		if err != nil {
+			d.fail(fieldPath, memberPtr, err)
+			continue
+		}
+
+		var member interface{}
+		switch {
+		case exists:
+			member, _ = f.ptr.Get(obj)
+		case f.hasDefault:
+			member = f.def
+		}
+
+		if f.required && member == nil {
+			d.fail(fieldPath, memberPtr, &KeyNotFoundError{Path: memberPtr.String()})
+			continue
+		}
+
+		if !exists && !f.hasDefault {
+			continue
+		}
+
+		target, ok := fieldByIndex(rv, f.index, true)
+		if !ok {
+			d.fail(fieldPath, memberPtr, errors.New("embedded struct cannot be allocated"))
+			continue
+		}
+		d.decode(fieldPath, memberPtr, member, target)
+	}
+
+	if d.options.errorUnused {
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			if !used[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			d.fail(field, ptr.Append(key), errors.Errorf("JSONPointer: %s is not mapped to any field", ptr.Append(key)))
+		}
+	}
+}
+
+func (d *structDecoder) decodeMap(field string, ptr Pointer, value interface{}, rv reflect.Value) {
+	obj, ok := asObject(value)
+	if !ok {
+		d.mismatch(field, ptr, rv, value)
+		return
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := reflect.MakeMapWithSize(rv.Type(), len(obj))
+	for _, key := range keys {
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		d.decode(fmt.Sprintf("%s[%s]", field, key), ptr.Append(key), obj[key], elem)
+		out.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), elem)
+	}
+	rv.Set(out)
+}
+
+func (d *structDecoder) decodeSlice(field string, ptr Pointer, value interface{}, rv reflect.Value) {
+	arr, ok := asArray(value)
+	if !ok {
+		d.mismatch(field, ptr, rv, value)
+		return
+	}
+
+	out := rv
+	if rv.Kind() == reflect.Slice {
+		out = reflect.MakeSlice(rv.Type(), len(arr), len(arr))
+	} else if len(arr) > rv.Len() {
+		d.fail(field, ptr, errors.Errorf("array length: %d exceeds length of %s", len(arr), rv.Type()))
+		return
+	}
+
+	for index, elem := range arr {
+		d.decode(fmt.Sprintf("%s[%d]", field, index), ptr.Append(strconv.Itoa(index)), elem, out.Index(index))
+	}
+
+	if rv.Kind() == reflect.Slice {
+		rv.Set(out)
+	}
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	basicKindTypes      = map[reflect.Kind]reflect.Type{
+		reflect.String:  reflect.TypeOf(""),
+		reflect.Bool:    reflect.TypeOf(false),
+		reflect.Int:     reflect.TypeOf(int(0)),
+		reflect.Int8:    reflect.TypeOf(int8(0)),
+		reflect.Int16:   reflect.TypeOf(int16(0)),
+		reflect.Int32:   reflect.TypeOf(int32(0)),
+		reflect.Int64:   reflect.TypeOf(int64(0)),
+		reflect.Uint:    reflect.TypeOf(uint(0)),
+		reflect.Uint8:   reflect.TypeOf(uint8(0)),
+		reflect.Uint16:  reflect.TypeOf(uint16(0)),
+		reflect.Uint32:  reflect.TypeOf(uint32(0)),
+		reflect.Uint64:  reflect.TypeOf(uint64(0)),
+		reflect.Float32: reflect.TypeOf(float32(0)),
+		reflect.Float64: reflect.TypeOf(float64(0)),
+	}
+)
+
+// convertReflect converts value by converter registered for t, or for basic type of t (named string, int, ...)
+// It returns false, if there is no such converter
+func convertReflect(value interface{}, t reflect.Type) (reflect.Value, bool, error) {
+	converters.RLock()
+	fn, exists := converters.funcs[t]
+	converters.RUnlock()
+
+	target := t
+	if !exists {
+		basic, isBasic := basicKindTypes[t.Kind()]
+		if !isBasic || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+			return reflect.Value{}, false, nil
+		}
+
+		converters.RLock()
+		fn, exists = converters.funcs[basic]
+		converters.RUnlock()
+		target = basic
+	}
+
+	if !exists {
+		return reflect.Value{}, false, nil
+	}
+
+	if reflect.TypeOf(value) == target {
+		return reflect.ValueOf(value).Convert(t), true, nil
+	}
+
+	converted, err := fn(value)
+	if err != nil {
+		return reflect.Value{}, true, err
+	}
+
+	return reflect.ValueOf(converted).Convert(t), true, nil
+}
+
+// encodeValue converts Go value to value stored in Rmap, field is Go path used in errors
+func encodeValue(field string, rv reflect.Value) (interface{}, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	switch v := rv.Interface().(type) {
+	case decimal.Decimal:
+		return json.Number(v.String()), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case Rmap:
+		if v.Mapa == nil {
+			return nil, nil
+		}
+		return copyMap(v.Mapa), nil
+	case json.Number:
+		return v, nil
+	}
+
+	if rv.Type().Implements(jsonMarshalerType) && !(rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return encodeJSON(field, rv.Interface())
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return encodeValue(field, rv.Elem())
+	case reflect.Struct:
+		return encodeStruct(field, rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil, nil
+		}
+
+		if rv.Type().Key().Kind() != reflect.String {
+			return encodeJSON(field, rv.Interface())
+		}
+
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			elem, err := encodeValue(fmt.Sprintf("%s[%s]", field, key), iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			out[key] = elem
+		}
+		return out, nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// base64 string like in encoding/json
+			return encodeJSON(field, rv.Interface())
+		}
+
+		out := make([]interface{}, rv.Len())
+		for index := range out {
+			elem, err := encodeValue(fmt.Sprintf("%s[%d]", field, index), rv.Index(index))
+			if err != nil {
+				return nil, err
+			}
+			out[index] = elem
+		}
+		return out, nil
+	}
+
+	if basic, ok := basicKindTypes[rv.Kind()]; ok {
+		return rv.Convert(basic).Interface(), nil
+	}
+
+	return nil, &FieldError{Field: field, Err: errors.Errorf("unsupported type: %s", rv.Type())}
+}
+
+func encodeStruct(field string, rv reflect.Value) (interface{}, error) {
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return nil, &FieldError{Field: field, Err: err}
+	}
+
+	out := NewEmpty()
+	for _, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index, false)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+
+		fieldPath := f.name
+		if field != "" {
+			fieldPath = field + "." + f.name
+		}
+
+		encoded, err := encodeValue(fieldPath, fv)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := out.SetJPtrRecursive(f.ptr.String(), encoded); err != nil {
+			return nil, &FieldError{Field: fieldPath, Pointer: f.ptr.String(), Err: err}
+		}
+	}
+
+	return out.Mapa, nil
+}
+
+func encodeJSON(field string, value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, &FieldError{Field: field, Err: err}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, &FieldError{Field: field, Err: err}
+	}
+
+	return out, nil
+}
+
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
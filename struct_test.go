@@ -0,0 +1,108 @@
+package rmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatus string
+
+type testMeta struct {
+	Created time.Time `json:"created"`
+}
+
+type testPort struct {
+	Port     uint16 `json:"port" rmap:",required"`
+	Protocol string `json:"protocol" rmap:",default=TCP"`
+}
+
+type testService struct {
+	testMeta
+	Name     string            `json:"name" rmap:",required"`
+	Owner    string            `rmap:"/metadata/owner"`
+	Status   testStatus        `json:"status,omitempty"`
+	Price    decimal.Decimal   `json:"price"`
+	Replicas int               `json:"replicas" rmap:",default=1"`
+	Ports    []testPort        `json:"ports,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Extra    interface{}       `json:"extra,omitempty"`
+	Parent   *testService      `json:"parent,omitempty"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+func TestDecode(t *testing.T) {
+	rm := MustNewFromString(`{"name":"web","created":"2024-01-02T03:04:05Z","metadata":{"owner":"team"},"status":"active","price":"10.10","ports":[{"port":80},{"port":53,"protocol":"UDP"}],"labels":{"app":"web"},"extra":[1],"parent":{"name":"base"},"Ignored":"x"}`)
+
+	var service testService
+	assert.Nil(t, rm.Decode(&service))
+	assert.Equal(t, "web", service.Name)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), service.Created)
+	assert.Equal(t, "team", service.Owner)
+	assert.Equal(t, testStatus("active"), service.Status)
+	assert.Equal(t, "10.1", service.Price.String())
+	assert.Equal(t, 1, service.Replicas)
+	assert.Equal(t, []testPort{{Port: 80, Protocol: "TCP"}, {Port: 53, Protocol: "UDP"}}, service.Ports)
+	assert.Equal(t, map[string]string{"app": "web"}, service.Labels)
+	assert.Equal(t, []interface{}{float64(1)}, service.Extra)
+	assert.Equal(t, "base", service.Parent.Name)
+	assert.Equal(t, "", service.Ignored)
+
+	err := MustNewFromString(`{"ports":[{"port":70000},{"protocol":1}],"price":"abc"}`).Decode(&service)
+	var decodeErr *DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, []string{"Name", "Price", "Ports[0].Port", "Ports[1].Port", "Ports[1].Protocol"}, fieldErrorPaths(decodeErr))
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	assert.True(t, errors.Is(err, ErrConversion))
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+	assert.Equal(t, "/ports/1/protocol", decodeErr.Errors[4].Pointer)
+
+	err = MustNewFromString(`{"name":"x","unknown":1}`).Decode(&testPort{}, ErrorUnused())
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, []string{"Port", "", ""}, fieldErrorPaths(decodeErr))
+	assert.Equal(t, "/unknown", decodeErr.Errors[2].Pointer)
+
+	assert.NotNil(t, rm.Decode(service))
+}
+
+func fieldErrorPaths(err *DecodeError) []string {
+	paths := []string{}
+	for _, fieldErr := range err.Errors {
+		paths = append(paths, fieldErr.Field)
+	}
+	return paths
+}
+
+func TestNewFromStruct(t *testing.T) {
+	service := testService{
+		testMeta: testMeta{Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		Name:     "web",
+		Owner:    "team",
+		Price:    decimal.RequireFromString("10.10"),
+		Replicas: 2,
+		Ports:    []testPort{{Port: 80, Protocol: "TCP"}},
+		internal: "x",
+	}
+
+	rm, err := NewFromStruct(&service)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"created":"2024-01-02T03:04:05Z","metadata":{"owner":"team"},"name":"web","ports":[{"port":80,"protocol":"TCP"}],"price":10.1,"replicas":2}`, rm.String())
+
+	var decoded testService
+	assert.Nil(t, rm.Decode(&decoded))
+	assert.Equal(t, service.Created, decoded.Created)
+	assert.True(t, service.Price.Equal(decoded.Price))
+	assert.Equal(t, service.Ports, decoded.Ports)
+
+	_, err = NewFromStruct("x")
+	assert.NotNil(t, err)
+
+	_, err = NewFromStruct(struct {
+		Bad string `rmap:",unknown"`
+	}{})
+	assert.NotNil(t, err)
+}
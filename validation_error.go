@@ -0,0 +1,372 @@
+package rmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/jsonschema"
+)
+
+// ProblemContentType is media type of document returned by ValidationError.ProblemDetails()
+const ProblemContentType = "application/problem+json"
+
+// SchemaViolation is one failed JSON Schema keyword
+type SchemaViolation struct {
+	InstancePointer string      // JSONPointer of invalid value, empty for root
+	SchemaPointer   string      // JSONPointer of failed keyword in schema, empty if it cannot be located, best-effort like Keyword
+	Keyword         string      // failed keyword, for example required, empty if unknown, best-effort: recognized from message of validator library
+	Message         string      // human-readable description, invalid string value is masked, if it is redacted
+	Value           interface{} // invalid value, redacted by policy set by SetErrorRedactionPolicy at time of validation
+}
+
+// ValidationError is returned, when Rmap does not satisfy JSON Schema
+// Use errors.As() to get it, errors.Is(err, rmap.ErrSchemaValidation) matches it
+type ValidationError struct {
+	Violations []SchemaViolation // sorted by InstancePointer and SchemaPointer
+}
+
+// Error joins all violations into one sorted multi-line string
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		path := violation.InstancePointer
+		if path == "" {
+			path = "/"
+		}
+
+		lines = append(lines, fmt.Sprintf("InvalidValue: %s, PropertyPath: %s, Message: %s", violation.Value, path, violation.Message))
+	}
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrSchemaValidation
+}
+
+// GroupByPath returns violations grouped by InstancePointer
+func (e *ValidationError) GroupByPath() map[string][]SchemaViolation {
+	groups := map[string][]SchemaViolation{}
+	for _, violation := range e.Violations {
+		groups[violation.InstancePointer] = append(groups[violation.InstancePointer], violation)
+	}
+
+	return groups
+}
+
+// ProblemDetails returns RFC 7807 problem details document with status 422
+// Violations are listed in extension member errors as objects with pointer, schemaPointer, keyword and detail
+// Invalid values are not included, type, title and other members can be changed in returned Rmap
+func (e *ValidationError) ProblemDetails() Rmap {
+	violations := make([]interface{}, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		violations = append(violations, map[string]interface{}{
+			"pointer":       violation.InstancePointer,
+			"schemaPointer": violation.SchemaPointer,
+			"keyword":       violation.Keyword,
+			"detail":        violation.Message,
+		})
+	}
+
+	return NewFromMap(map[string]interface{}{
+		"type":   "about:blank",
+		"title":  "Unprocessable Entity",
+		"status": 422,
+		"detail": fmt.Sprintf("document does not satisfy JSON Schema, violations: %d", len(e.Violations)),
+		"errors": violations,
+	})
+}
+
+// ProblemJSON returns ProblemDetails() serialized to JSON, it should be sent with ProblemContentType
+func (e *ValidationError) ProblemJSON() []byte {
+	return e.ProblemDetails().Bytes()
+}
+
+// schemaErrors converts validation errors into *ValidationError, or returns nil
+// schema is used to locate failed keywords, it can be nil
+func schemaErrors(errs []jsonschema.KeyError, schema map[string]interface{}) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	locator := schemaLocator{root: schema}
+	violations := make([]SchemaViolation, 0, len(errs))
+	for _, err := range errs {
+		instance := err.PropertyPath
+		if instance == "/" {
+			instance = ""
+		}
+
+		violation := SchemaViolation{
+			InstancePointer: instance,
+			Keyword:         schemaKeywordOf(err.Message),
+			Message:         err.Message,
+			Value:           redactErrorInterface(instance, err.InvalidValue),
+		}
+
+		// library includes invalid string in some messages
+		if raw, isString := err.InvalidValue.(string); isString && raw != "" && !reflect.DeepEqual(violation.Value, err.InvalidValue) {
+			violation.Message = strings.ReplaceAll(violation.Message, raw, fmt.Sprintf("%v", violation.Value))
+		}
+
+		if ptr, perr := ParsePointer(instance); perr == nil && schema != nil {
+			if location, ok := locator.locate(ptr, violation.Keyword); ok {
+				violation.SchemaPointer = location.String()
+			}
+		}
+
+		violations = append(violations, violation)
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].InstancePointer != violations[j].InstancePointer {
+			return violations[i].InstancePointer < violations[j].InstancePointer
+		}
+		return violations[i].SchemaPointer < violations[j].SchemaPointer
+	})
+
+	return &ValidationError{Violations: violations}
+}
+
+// validator library reports only messages, keyword is recognized from them
+// Patterns are matched in order, so more specific ones go first (minimum before exclusiveMinimum)
+// Messages are pinned by TestSchemaKeywordMessages, update of the library must be checked against it
+var schemaKeywordMessages = []struct {
+	re      *regexp.Regexp
+	keyword string
+}{
+	{regexp.MustCompile(`^type should be`), "type"},
+	{regexp.MustCompile(`should be one of`), "enum"},
+	{regexp.MustCompile(`must equal`), "const"},
+	{regexp.MustCompile(`must be a multiple of`), "multipleOf"},
+	{regexp.MustCompile(`must be greater than or equal to`), "minimum"},
+	{regexp.MustCompile(`must be less than or equal to`), "maximum"},
+	{regexp.MustCompile(`must be greater than`), "exclusiveMinimum"},
+	{regexp.MustCompile(`must be less than`), "exclusiveMaximum"},
+	{regexp.MustCompile(`^max length of`), "maxLength"},
+	{regexp.MustCompile(`^min length of`), "minLength"},
+	{regexp.MustCompile(`^regexp pattern`), "pattern"},
+	{regexp.MustCompile(`^invalid \S+:`), "format"},
+	{regexp.MustCompile(`value is required$`), "required"},
+	{regexp.MustCompile(`property is required$`), "dependentRequired"},
+	{regexp.MustCompile(`object Properties below`), "minProperties"},
+	{regexp.MustCompile(`object Properties exceed`), "maxProperties"},
+	{regexp.MustCompile(`^additional properties`), "additionalProperties"},
+	{regexp.MustCompile(`^unevaluated properties`), "unevaluatedProperties"},
+	{regexp.MustCompile(`^additional items`), "additionalItems"},
+	{regexp.MustCompile(`^unevaluated items`), "unevaluatedItems"},
+	{regexp.MustCompile(`^array items must be unique`), "uniqueItems"},
+	{regexp.MustCompile(`minimum items$`), "minItems"},
+	{regexp.MustCompile(`^array length \d+ exceeds`), "maxItems"},
+	{regexp.MustCompile(`^contained items \d+ bellow`), "minContains"},
+	{regexp.MustCompile(`^contained items \d+ exceeds`), "maxContains"},
+	{regexp.MustCompile(`^must contain at least one of`), "contains"},
+	{regexp.MustCompile(`AnyOf`), "anyOf"},
+	{regexp.MustCompile(`OneOf`), "oneOf"},
+	{regexp.MustCompile(`\('not'\)`), "not"},
+	{regexp.MustCompile(`^failed to resolve schema for ref`), "$ref"},
+}
+
+func schemaKeywordOf(message string) string {
+	for _, candidate := range schemaKeywordMessages {
+		if candidate.re.MatchString(message) {
+			return candidate.keyword
+		}
+	}
+
+	return ""
+}
+
+// maximum number of local $refs followed in a row, protects from cycles
+const maxSchemaRefDepth = 32
+
+// schemaLocator finds subschemas of root applying to instance locations
+type schemaLocator struct {
+	root map[string]interface{}
+}
+
+// locate returns JSONPointer of keyword in subschema applying to instance, or of subschema itself, if keyword is empty
+func (l schemaLocator) locate(instance Pointer, keyword string) (Pointer, bool) {
+	loc, node, ok := l.resolve(Pointer{}, l.root)
+	for _, token := range instance {
+		if !ok {
+			return nil, false
+		}
+		loc, node, ok = l.child(loc, node, token)
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	if keyword == "" {
+		return loc, true
+	}
+
+	if found, ok := l.keyword(loc, node, keyword); ok {
+		return found, true
+	}
+
+	return loc.Append(keyword), true
+}
+
+// resolve follows local $refs of node
+func (l schemaLocator) resolve(loc Pointer, node map[string]interface{}) (Pointer, map[string]interface{}, bool) {
+	for depth := 0; depth < maxSchemaRefDepth; depth++ {
+		ref, isRef := node["$ref"].(string)
+		if !isRef {
+			return loc, node, true
+		}
+
+		if !strings.HasPrefix(ref, "#") {
+			// external reference, location is not in this document
+			return loc, node, true
+		}
+
+		fragment, err := url.PathUnescape(ref[1:])
+		if err != nil {
+			return nil, nil, false
+		}
+
+		target, err := ParsePointer(fragment)
+		if err != nil {
+			return nil, nil, false
+		}
+
+		value, err := target.Get(l.root)
+		if err != nil {
+			return nil, nil, false
+		}
+
+		obj, ok := asObject(value)
+		if !ok {
+			return nil, nil, false
+		}
+
+		loc, node = target, obj
+	}
+
+	return nil, nil, false
+}
+
+// child returns subschema of node applying to member token of instance
+func (l schemaLocator) child(loc Pointer, node map[string]interface{}, token string) (Pointer, map[string]interface{}, bool) {
+	if props, ok := asObject(node["properties"]); ok {
+		if sub, ok := asObject(props[token]); ok {
+			return l.resolve(loc.Append("properties", token), sub)
+		}
+	}
+
+	if patterns, ok := asObject(node["patternProperties"]); ok {
+		names := make([]string, 0, len(patterns))
+		for name := range patterns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			sub, isObj := asObject(patterns[name])
+			if matched, err := regexp.MatchString(name, token); err == nil && matched && isObj {
+				return l.resolve(loc.Append("patternProperties", name), sub)
+			}
+		}
+	}
+
+	if index, err := strconv.Atoi(token); err == nil && index >= 0 {
+		if sub, ok := asObject(node["items"]); ok {
+			return l.resolve(loc.Append("items"), sub)
+		}
+
+		if tuple, ok := asArray(node["items"]); ok {
+			if index < len(tuple) {
+				if sub, ok := asObject(tuple[index]); ok {
+					return l.resolve(loc.Append("items", strconv.Itoa(index)), sub)
+				}
+			} else if sub, ok := asObject(node["additionalItems"]); ok {
+				return l.resolve(loc.Append("additionalItems"), sub)
+			}
+		}
+	}
+
+	// member can be declared in one of combined subschemas
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		branches, _ := asArray(node[keyword])
+		for index, branch := range branches {
+			sub, ok := asObject(branch)
+			if !ok {
+				continue
+			}
+
+			subLoc, subNode, ok := l.resolve(loc.Append(keyword, strconv.Itoa(index)), sub)
+			if !ok {
+				continue
+			}
+
+			if found, foundNode, ok := l.child(subLoc, subNode, token); ok {
+				return found, foundNode, true
+			}
+		}
+	}
+
+	if sub, ok := asObject(node["additionalProperties"]); ok {
+		return l.resolve(loc.Append("additionalProperties"), sub)
+	}
+
+	return nil, nil, false
+}
+
+// keyword returns location of keyword in node or in its combined subschemas
+func (l schemaLocator) keyword(loc Pointer, node map[string]interface{}, keyword string) (Pointer, bool) {
+	if _, exists := node[keyword]; exists {
+		return loc.Append(keyword), true
+	}
+
+	for _, combinator := range []string{"allOf", "anyOf", "oneOf", "then", "else"} {
+		branches, isArr := asArray(node[combinator])
+		if !isArr {
+			if sub, ok := asObject(node[combinator]); ok {
+				branches = []interface{}{sub}
+			}
+		}
+
+		for index, branch := range branches {
+			sub, ok := asObject(branch)
+			if !ok {
+				continue
+			}
+
+			branchLoc := loc.Append(combinator)
+			if isArr {
+				branchLoc = branchLoc.Append(strconv.Itoa(index))
+			}
+
+			subLoc, subNode, ok := l.resolve(branchLoc, sub)
+			if !ok {
+				continue
+			}
+
+			if found, ok := l.keyword(subLoc, subNode, keyword); ok {
+				return found, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// schemaMap decodes schema for schemaLocator, it returns nil if schema is not JSON object
+func schemaMap(schema []byte) map[string]interface{} {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(schema, &obj); err != nil {
+		return nil
+	}
+
+	return obj
+}
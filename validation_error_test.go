@@ -0,0 +1,163 @@
+package rmap
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validationErrorSchema = `{"type":"object","properties":{"name":{"type":"string","minLength":3},"age":{"$ref":"#/$defs/age"},"tags":{"type":"array","items":{"type":"string"}}},"required":["name","email"],"$defs":{"age":{"type":"integer","minimum":0}}}`
+
+func TestValidationError(t *testing.T) {
+	rm := MustNewFromString(`{"name":"ab","age":-1,"tags":["a",2]}`)
+
+	err := rm.ValidateSchemaBytes([]byte(validationErrorSchema))
+	assert.True(t, errors.Is(err, ErrSchemaValidation))
+
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, []SchemaViolation{
+		{InstancePointer: "", SchemaPointer: "/required", Keyword: "required", Message: `"email" value is required`, Value: rm.Mapa},
+		{InstancePointer: "/age", SchemaPointer: "/$defs/age/minimum", Keyword: "minimum", Message: "must be greater than or equal to 0", Value: float64(-1)},
+		{InstancePointer: "/name", SchemaPointer: "/properties/name/minLength", Keyword: "minLength", Message: "min length of 3 characters required: ab", Value: "ab"},
+		{InstancePointer: "/tags/1", SchemaPointer: "/properties/tags/items/type", Keyword: "type", Message: "type should be string, got integer", Value: float64(2)},
+	}, verr.Violations)
+
+	groups := verr.GroupByPath()
+	assert.Len(t, groups, 4)
+	assert.Len(t, groups["/age"], 1)
+	assert.Equal(t, "minimum", groups["/age"][0].Keyword)
+
+	// validator locates keywords in compiled schema
+	validator, err := CompileSchema(MustNewFromString(validationErrorSchema))
+	assert.Nil(t, err)
+
+	err = validator.Validate(MustNewFromString(`{"name":"abc","email":"a@b","age":-1}`))
+	assert.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Violations, 1)
+	assert.Equal(t, "/age", verr.Violations[0].InstancePointer)
+	assert.Equal(t, "/$defs/age/minimum", verr.Violations[0].SchemaPointer)
+
+	assert.Nil(t, validator.Validate(MustNewFromString(`{"name":"abc","email":"a@b"}`)))
+}
+
+func TestValidationErrorString(t *testing.T) {
+	doc := MustNewFromString(`{"password":"secret-value","nested":{"password":"x"}}`)
+	schema := []byte(`{"properties":{"password":{"type":"integer","minLength":20}},"required":["missing"]}`)
+
+	err := doc.ValidateSchemaBytes(schema)
+	assert.Contains(t, err.Error(), "InvalidValue: secret-value, PropertyPath: /password, Message: type should be integer, got string")
+
+	// violations are redacted, when they are created, so logging them does not leak values
+	defer SetErrorRedactionPolicy(nil)
+	SetErrorRedactionPolicy([]RedactRule{{Key: "password"}})
+	err = doc.ValidateSchemaBytes(schema)
+	assert.Contains(t, err.Error(), "InvalidValue: [REDACTED], PropertyPath: /password, Message: type should be integer, got string")
+
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.NotContains(t, fmt.Sprintf("%+v", verr.Violations), "secret-value")
+	assert.NotContains(t, fmt.Sprintf("%+v", verr.Violations), `password:x`)
+	assert.Equal(t, map[string]interface{}{"password": RedactedValue, "nested": map[string]interface{}{"password": RedactedValue}}, verr.Violations[0].Value)
+}
+
+func TestValidationErrorProblemDetails(t *testing.T) {
+	err := MustNewFromString(`{"name":1}`).ValidateSchemaBytes([]byte(`{"properties":{"name":{"type":"string"}}}`))
+
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+
+	problem := verr.ProblemDetails()
+	assert.Equal(t, "about:blank", problem.MustGetString("type"))
+	assert.Equal(t, 422, problem.MustGetInt("status"))
+	assert.Equal(t, `{"detail":"document does not satisfy JSON Schema, violations: 1","errors":[{"detail":"type should be string, got integer","keyword":"type","pointer":"/name","schemaPointer":"/properties/name/type"}],"status":422,"title":"Unprocessable Entity","type":"about:blank"}`, string(verr.ProblemJSON()))
+}
+
+func TestSchemaLocator(t *testing.T) {
+	schema := MustNewFromString(`{"allOf":[{"properties":{"a":{"type":"string"}}}],"patternProperties":{"^x-":{"maxLength":1}},"properties":{"t":{"items":[{"type":"string"}],"additionalItems":{"type":"number"}}},"additionalProperties":{"type":"boolean"}}`).Mapa
+	locator := schemaLocator{root: schema}
+
+	for _, tc := range []struct{ instance, keyword, expected string }{
+		{"/a", "type", "/allOf/0/properties/a/type"},
+		{"/x-y", "maxLength", "/patternProperties/^x-/maxLength"},
+		{"/t/0", "type", "/properties/t/items/0/type"},
+		{"/t/5", "type", "/properties/t/additionalItems/type"},
+		{"/z", "type", "/additionalProperties/type"},
+	} {
+		location, ok := locator.locate(MustParsePointer(tc.instance), tc.keyword)
+		assert.True(t, ok, tc.instance)
+		assert.Equal(t, tc.expected, location.String(), tc.instance)
+	}
+
+	_, ok := schemaLocator{root: map[string]interface{}{"properties": map[string]interface{}{}}}.locate(MustParsePointer("/missing"), "type")
+	assert.False(t, ok)
+}
+
+// pins messages of validator library, Keyword is recognized from them
+func TestSchemaKeywordMessages(t *testing.T) {
+	cases := []struct {
+		keyword string
+		schema  string
+		value   string
+	}{
+		{"type", `{"type":"string"}`, `1`},
+		{"enum", `{"enum":["a"]}`, `"b"`},
+		{"const", `{"const":"a"}`, `"b"`},
+		{"multipleOf", `{"multipleOf":2}`, `3`},
+		{"minimum", `{"minimum":5}`, `1`},
+		{"maximum", `{"maximum":5}`, `9`},
+		{"exclusiveMinimum", `{"exclusiveMinimum":5}`, `5`},
+		{"exclusiveMaximum", `{"exclusiveMaximum":5}`, `5`},
+		{"maxLength", `{"maxLength":1}`, `"ab"`},
+		{"minLength", `{"minLength":3}`, `"ab"`},
+		{"pattern", `{"pattern":"^a"}`, `"b"`},
+		{"format", `{"format":"email"}`, `"x"`},
+		{"required", `{"required":["x"]}`, `{}`},
+		{"dependentRequired", `{"dependentRequired":{"a":["b"]}}`, `{"a":1}`},
+		{"minProperties", `{"minProperties":2}`, `{}`},
+		{"maxProperties", `{"maxProperties":0}`, `{"a":1}`},
+		{"additionalProperties", `{"additionalProperties":false}`, `{"a":1}`},
+		{"unevaluatedProperties", `{"unevaluatedProperties":false}`, `{"a":1}`},
+		{"additionalItems", `{"items":[{}],"additionalItems":false}`, `[1,2]`},
+		{"unevaluatedItems", `{"items":[{}],"unevaluatedItems":false}`, `[1,2]`},
+		{"uniqueItems", `{"uniqueItems":true}`, `[1,1]`},
+		{"minItems", `{"minItems":2}`, `[1]`},
+		{"maxItems", `{"maxItems":0}`, `[1]`},
+		{"minContains", `{"contains":{"const":1},"minContains":2}`, `[1]`},
+		{"maxContains", `{"contains":{"const":1},"maxContains":1}`, `[1,1]`},
+		{"contains", `{"contains":{"const":1}}`, `[2]`},
+		{"anyOf", `{"anyOf":[{"type":"string"}]}`, `1`},
+		{"oneOf", `{"oneOf":[{"type":"string"}]}`, `1`},
+		{"not", `{"not":{"type":"integer"}}`, `1`},
+		{"$ref", `{"$ref":"#/$defs/missing"}`, `1`},
+	}
+
+	covered := map[string]bool{}
+	for _, c := range cases {
+		covered[c.keyword] = true
+
+		err := MustNewFromString(`{"v":` + c.value + `}`).ValidateSchemaBytes([]byte(`{"properties":{"v":` + c.schema + `}}`))
+
+		var verr *ValidationError
+		if !assert.True(t, errors.As(err, &verr), c.keyword) {
+			continue
+		}
+
+		violation := verr.Violations[0]
+		assert.Equal(t, c.keyword, violation.Keyword, violation.Message)
+		assert.Equal(t, "/v", violation.InstancePointer, c.keyword)
+
+		// unresolvable $ref cannot be located
+		if c.keyword == "$ref" {
+			assert.Equal(t, "", violation.SchemaPointer)
+		} else {
+			assert.Equal(t, "/properties/v/"+c.keyword, violation.SchemaPointer)
+		}
+	}
+
+	for _, candidate := range schemaKeywordMessages {
+		assert.True(t, covered[candidate.keyword], candidate.keyword)
+	}
+}
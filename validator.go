@@ -0,0 +1,550 @@
+package rmap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/qri-io/jsonschema"
+)
+
+// SchemaRegistry holds JSON Schemas keyed by $id, which can be referenced by $ref from compiled schemas
+// References to schemas which are not registered are loaded from local files (file:// URI or relative path), network is never accessed
+// SchemaRegistry is safe for concurrent use
+type SchemaRegistry struct {
+	mu         sync.RWMutex
+	schemas    map[string]map[string]interface{}
+	validators map[string]*Validator
+	version    uint64 // incremented by every registration
+}
+
+// DefaultSchemaRegistry is used by RegisterSchema and CompileSchema
+var DefaultSchemaRegistry = NewSchemaRegistry()
+
+var loadSchemaKeywords sync.Once
+
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas:    map[string]map[string]interface{}{},
+		validators: map[string]*Validator{},
+	}
+}
+
+// RegisterSchema registers schema in DefaultSchemaRegistry
+func RegisterSchema(schema Rmap) error {
+	return DefaultSchemaRegistry.Register(schema)
+}
+
+// CompileSchema compiles schema using DefaultSchemaRegistry
+func CompileSchema(schema Rmap) (*Validator, error) {
+	return DefaultSchemaRegistry.Compile(schema)
+}
+
+// Register registers schema under its $id, which must be set
+// Existing schema with the same $id is replaced
+func (sr *SchemaRegistry) Register(schema Rmap) error {
+	id, err := schema.GetString("$id")
+	if err != nil {
+		return errors.Wrapf(err, "schema.GetString() failed")
+	}
+
+	return sr.register(schemaURI("", id), schema)
+}
+
+// RegisterFile registers schema loaded from JSON or YAML file under its file:// URI, and also under its $id, if it is set
+func (sr *SchemaRegistry) RegisterFile(path string) error {
+	uri := schemaURI("", path)
+
+	schema, err := loadSchemaFile(uri)
+	if err != nil {
+		return err
+	}
+
+	if err := sr.register(uri, NewFromMap(schema)); err != nil {
+		return err
+	}
+
+	if id, ok := schema["$id"].(string); ok {
+		return sr.register(schemaURI(uri, id), NewFromMap(schema))
+	}
+
+	return nil
+}
+
+func (sr *SchemaRegistry) register(uri string, schema Rmap) error {
+	normalized, err := normalizeJSONValue(schema)
+	if err != nil {
+		return err
+	}
+
+	obj, ok := asObject(normalized)
+	if !ok {
+		return fmt.Errorf("schema: %s is not an object", uri)
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	sr.schemas[uri] = obj
+	sr.version++
+	// any compiled schema can reference replaced one
+	sr.validators = map[string]*Validator{}
+	return nil
+}
+
+// Validator returns compiled registered schema with $id, compiled Validator is cached until next registration
+func (sr *SchemaRegistry) Validator(id string) (*Validator, error) {
+	uri := schemaURI("", id)
+
+	for {
+		sr.mu.RLock()
+		validator, cached := sr.validators[uri]
+		schema, registered := sr.schemas[uri]
+		version := sr.version
+		sr.mu.RUnlock()
+
+		if cached {
+			return validator, nil
+		}
+
+		if !registered {
+			return nil, fmt.Errorf("schema: %s is not registered", uri)
+		}
+
+		// compilation is done without lock, it can load files
+		validator, err := sr.compile(NewFromMap(schema), uri)
+		if err != nil {
+			return nil, err
+		}
+
+		sr.mu.Lock()
+		if sr.version != version {
+			// registration happened during compilation, validator can be built from old schemas
+			sr.mu.Unlock()
+			continue
+		}
+
+		if existing, cached := sr.validators[uri]; cached {
+			// other caller was faster, all callers get the same instance
+			validator = existing
+		} else {
+			sr.validators[uri] = validator
+		}
+		sr.mu.Unlock()
+
+		return validator, nil
+	}
+}
+
+// Compile resolves all $ref in schema and returns Validator
+// Referenced schemas are embedded into $defs, so Validator does not depend on registry later
+// Draft-07 definitions and dependencies are translated to $defs, dependentRequired and dependentSchemas
+func (sr *SchemaRegistry) Compile(schema Rmap) (*Validator, error) {
+	return sr.compile(schema, "")
+}
+
+// compile compiles schema, relative references are resolved against $id of schema or against uri, if $id is not set
+func (sr *SchemaRegistry) compile(schema Rmap, uri string) (*Validator, error) {
+	root, err := sr.bundle(schema, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return newValidator(root)
+}
+
+// bundle returns copy of schema with all external references embedded into $defs
+func (sr *SchemaRegistry) bundle(schema Rmap, uri string) (map[string]interface{}, error) {
+	normalized, err := normalizeJSONValue(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	root, ok := asObject(normalized)
+	if !ok {
+		return nil, errors.New("schema is not an object")
+	}
+
+	rootURI := uri
+	if id, ok := root["$id"].(string); ok && !strings.HasPrefix(id, "#") {
+		rootURI = schemaURI(uri, id)
+	}
+
+	translateDraft7(root)
+	bundler := &schemaBundler{registry: sr, names: map[string]string{}, defs: map[string]interface{}{}, reserved: map[string]bool{}}
+	if defs, ok := asObject(root["$defs"]); ok {
+		for name := range defs {
+			bundler.reserved[name] = true
+		}
+	}
+
+	if err := bundler.rewrite(root, rootURI, rootURI, Pointer{}); err != nil {
+		return nil, err
+	}
+
+	if len(bundler.defs) > 0 {
+		defs, _ := asObject(root["$defs"])
+		if defs == nil {
+			defs = map[string]interface{}{}
+		}
+
+		for name, def := range bundler.defs {
+			defs[name] = def
+		}
+		root["$defs"] = defs
+	}
+
+	return root, nil
+}
+
+// lookup returns registered schema, or schema loaded from local file
+func (sr *SchemaRegistry) lookup(uri string) (map[string]interface{}, error) {
+	sr.mu.RLock()
+	schema, registered := sr.schemas[uri]
+	sr.mu.RUnlock()
+
+	if registered {
+		return schema, nil
+	}
+
+	if !strings.HasPrefix(uri, "file://") {
+		return nil, fmt.Errorf("schema: %s is not registered and network access is disabled", uri)
+	}
+
+	schema, err := loadSchemaFile(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	sr.mu.Lock()
+	sr.schemas[uri] = schema
+	sr.mu.Unlock()
+
+	return schema, nil
+}
+
+func loadSchemaFile(uri string) (map[string]interface{}, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, errors.Wrapf(err, "url.Parse() failed")
+	}
+
+	path := filepath.FromSlash(parsed.Path)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ioutil.ReadFile() failed")
+	}
+
+	var schema Rmap
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		schema, err = NewFromYAMLBytes(data)
+	default:
+		schema, err = NewFromBytes(data)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "schema file: %s cannot be parsed", path)
+	}
+
+	normalized, err := normalizeJSONValue(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, _ := asObject(normalized)
+	return obj, nil
+}
+
+// schemaURI resolves ref against base and removes fragment
+// Relative ref without base is path to local file
+func schemaURI(base, ref string) string {
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+
+	var resolved *url.URL
+	switch {
+	case base != "":
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return ref
+		}
+		resolved = baseURL.ResolveReference(refURL)
+	case refURL.IsAbs():
+		resolved = refURL
+	default:
+		path, err := filepath.Abs(filepath.FromSlash(refURL.Path))
+		if err != nil {
+			return ref
+		}
+		resolved = &url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	}
+
+	resolved.Fragment = ""
+	return resolved.String()
+}
+
+// translateDraft7 converts draft-07 keywords to draft 2019-09 ones, which are supported by validator
+func translateDraft7(schema map[string]interface{}) {
+	if definitions, ok := asObject(schema["definitions"]); ok {
+		defs, _ := asObject(schema["$defs"])
+		if defs == nil {
+			defs = map[string]interface{}{}
+		}
+
+		for name, def := range definitions {
+			if _, exists := defs[name]; !exists {
+				defs[name] = def
+			}
+		}
+		schema["$defs"] = defs
+		delete(schema, "definitions")
+	}
+
+	if dependencies, ok := asObject(schema["dependencies"]); ok {
+		required, schemas := map[string]interface{}{}, map[string]interface{}{}
+		for name, dependency := range dependencies {
+			if _, isArr := asArray(dependency); isArr {
+				required[name] = dependency
+			} else {
+				schemas[name] = dependency
+			}
+		}
+
+		if len(required) > 0 {
+			schema["dependentRequired"] = required
+		}
+		if len(schemas) > 0 {
+			schema["dependentSchemas"] = schemas
+		}
+		delete(schema, "dependencies")
+	}
+}
+
+// translateSchemaTokens translates draft-07 keywords in JSONPointer to schema location, see translateDraft7
+func translateSchemaTokens(tokens []string) []string {
+	out := append([]string{}, tokens...)
+
+	keywordPosition := true
+	for index, token := range out {
+		if !keywordPosition {
+			keywordPosition = true
+			continue
+		}
+
+		switch {
+		case token == "definitions":
+			out[index] = "$defs"
+			keywordPosition = false
+		case token == "dependencies":
+			out[index] = "dependentSchemas"
+			keywordPosition = false
+		case token == "items":
+			// next token is index, if items is array
+			if index+1 < len(out) {
+				_, err := strconv.Atoi(out[index+1])
+				keywordPosition = err != nil
+			}
+		case schemaMapKeywords[token], schemaArrayKeywords[token]:
+			keywordPosition = false
+		case schemaSingleKeywords[token]:
+		default:
+			// not a subschema keyword, rest of pointer points to value
+			return out
+		}
+	}
+
+	return out
+}
+
+// schemaBundler embeds referenced schemas into $defs of root schema and rewrites $ref to local references
+type schemaBundler struct {
+	registry *SchemaRegistry
+	names    map[string]string      // URI of embedded schema -> name in $defs
+	defs     map[string]interface{} // name in $defs -> embedded schema
+	reserved map[string]bool        // names used in root $defs
+}
+
+// rewrite rewrites $ref in schema and its subschemas, base is URI used to resolve relative references
+// docURI and docPtr identify document containing schema and its location in bundled root schema
+func (b *schemaBundler) rewrite(schema map[string]interface{}, base, docURI string, docPtr Pointer) error {
+	if id, ok := schema["$id"].(string); ok && !strings.HasPrefix(id, "#") {
+		base = schemaURI(base, id)
+		delete(schema, "$id")
+	}
+
+	translateDraft7(schema)
+
+	if ref, ok := schema["$ref"].(string); ok {
+		rewritten, err := b.rewriteRef(ref, base, docURI, docPtr)
+		if err != nil {
+			return err
+		}
+		schema["$ref"] = rewritten
+	}
+
+	var err error
+	subschemas(schema, func(tokens []string, sub map[string]interface{}) {
+		if err == nil {
+			err = b.rewrite(sub, base, docURI, docPtr)
+		}
+	})
+
+	return err
+}
+
+func (b *schemaBundler) rewriteRef(ref, base, docURI string, docPtr Pointer) (string, error) {
+	address, fragment := ref, ""
+	if index := strings.Index(ref, "#"); index >= 0 {
+		address, fragment = ref[:index], ref[index+1:]
+	}
+
+	if address != "" {
+		uri := schemaURI(base, address)
+		if uri != docURI {
+			name, err := b.embed(uri)
+			if err != nil {
+				return "", errors.Wrapf(err, "$ref: %s cannot be resolved", ref)
+			}
+
+			docPtr = Pointer{"$defs", name}
+		}
+	}
+
+	if fragment == "" {
+		return "#" + docPtr.String(), nil
+	}
+
+	if !strings.HasPrefix(fragment, "/") {
+		// anchor, can be resolved only in root document
+		return "#" + fragment, nil
+	}
+
+	ptr, err := ParsePointer(fragment)
+	if err != nil {
+		return "", errors.Wrapf(err, "$ref: %s has invalid fragment", ref)
+	}
+
+	return "#" + docPtr.Append(translateSchemaTokens(ptr)...).String(), nil
+}
+
+// embed adds schema with URI to bundle and returns its name in $defs
+func (b *schemaBundler) embed(uri string) (string, error) {
+	if name, exists := b.names[uri]; exists {
+		return name, nil
+	}
+
+	schema, err := b.registry.lookup(uri)
+	if err != nil {
+		return "", err
+	}
+
+	name := ""
+	for index := len(b.names); name == "" || b.reserved[name]; index++ {
+		name = "ref" + strconv.Itoa(index)
+	}
+
+	// register name before rewriting, so cyclic references are resolved
+	b.names[uri] = name
+	b.reserved[name] = true
+
+	doc := DeepCopy(schema).(map[string]interface{})
+	if err := b.rewrite(doc, uri, uri, Pointer{"$defs", name}); err != nil {
+		return "", err
+	}
+
+	b.defs[name] = doc
+	return name, nil
+}
+
+// Validator is compiled JSON Schema, it is safe for concurrent use
+type Validator struct {
+	schema Rmap
+	pool   sync.Pool
+}
+
+func newValidator(schema map[string]interface{}) (*Validator, error) {
+	// all local references must point to existing subschema
+	var err error
+	walkSchema(schema, Pointer{}, func(ptr Pointer, sub map[string]interface{}) {
+		ref, ok := sub["$ref"].(string)
+		if !ok || err != nil || !strings.HasPrefix(ref, "#/") && ref != "#" {
+			return
+		}
+
+		target, parseErr := ParsePointer(ref[1:])
+		if parseErr != nil {
+			err = errors.Wrapf(parseErr, "$ref: %s in: %s is invalid", ref, ptr)
+			return
+		}
+
+		if exists, _ := target.Exists(schema); !exists {
+			err = fmt.Errorf("$ref: %s in: %s cannot be resolved", ref, ptr)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, errors.Wrapf(err, "json.Marshal() failed")
+	}
+
+	compiled, err := parseSchema(data)
+	if err != nil {
+		return nil, err
+	}
+
+	validator := &Validator{schema: NewFromMap(schema)}
+	// compiled schema caches resolved references during validation, so every goroutine needs its own instance
+	validator.pool.New = func() interface{} {
+		compiled, _ := parseSchema(data)
+		return compiled
+	}
+	validator.pool.Put(compiled)
+
+	return validator, nil
+}
+
+func parseSchema(data []byte) (*jsonschema.Schema, error) {
+	loadSchemaKeywords.Do(func() {
+		if !jsonschema.IsRegistryLoaded() {
+			jsonschema.LoadDraft2019_09()
+		}
+		jsonschema.GetSchemaRegistry()
+	})
+
+	schema := &jsonschema.Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, errors.Wrapf(err, "json.Unmarshal() failed")
+	}
+
+	return schema, nil
+}
+
+// Schema returns compiled schema with all references embedded
+func (v *Validator) Schema() Rmap {
+	return v.schema.Copy()
+}
+
+// Validate checks if Rmap satisfies compiled schema, returned error is *ValidationError like in ValidateSchemaBytes
+func (v *Validator) Validate(r Rmap) error {
+	compiled := v.pool.Get().(*jsonschema.Schema)
+	defer v.pool.Put(compiled)
+
+	errs, err := compiled.ValidateBytes(context.Background(), r.Bytes())
+	if err != nil {
+		return errors.Wrapf(err, "compiled.ValidateBytes() failed")
+	}
+
+	return schemaErrors(errs, v.schema.Mapa)
+}
@@ -0,0 +1,129 @@
+package rmap
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileSchema(t *testing.T) {
+	schema := MustNewFromString(`{"type":"object","properties":{"name":{"type":"string"},"overrides":{"type":"array","items":{"$ref":"#/definitions/override"}}},"required":["name"],"definitions":{"override":{"type":"object","required":["key"]}}}`)
+
+	validator, err := CompileSchema(schema)
+	assert.Nil(t, err)
+
+	assert.Nil(t, validator.Validate(MustNewFromString(`{"name":"foo","overrides":[{"key":"a"}]}`)))
+	assert.NotNil(t, validator.Validate(MustNewFromString(`{"name":"foo","overrides":[{}]}`)))
+	assert.NotNil(t, validator.Validate(MustNewFromString(`{"name":1}`)))
+
+	_, err = CompileSchema(MustNewFromString(`{"$ref":"#/definitions/missing"}`))
+	assert.NotNil(t, err)
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	registry := NewSchemaRegistry()
+
+	assert.Nil(t, registry.Register(MustNewFromString(`{"$id":"https://example.com/address.json","type":"object","properties":{"city":{"$ref":"#/definitions/nonEmpty"}},"required":["city"],"definitions":{"nonEmpty":{"type":"string","minLength":1}}}`)))
+	assert.Nil(t, registry.Register(MustNewFromString(`{"$id":"https://example.com/person.json","type":"object","properties":{"address":{"$ref":"address.json"},"city":{"$ref":"address.json#/definitions/nonEmpty"}}}`)))
+	assert.NotNil(t, registry.Register(MustNewFromString(`{"type":"object"}`)))
+
+	validator, err := registry.Validator("https://example.com/person.json")
+	assert.Nil(t, err)
+	assert.Nil(t, validator.Validate(MustNewFromString(`{"address":{"city":"Prague"},"city":"Brno"}`)))
+	assert.NotNil(t, validator.Validate(MustNewFromString(`{"address":{"city":""}}`)))
+	assert.NotNil(t, validator.Validate(MustNewFromString(`{"address":{}}`)))
+	assert.NotNil(t, validator.Validate(MustNewFromString(`{"city":""}`)))
+
+	cached, err := registry.Validator("https://example.com/person.json")
+	assert.Nil(t, err)
+	assert.True(t, validator == cached)
+
+	// no network access
+	_, err = registry.Compile(MustNewFromString(`{"$ref":"https://example.com/unknown.json"}`))
+	assert.NotNil(t, err)
+}
+
+func TestSchemaRegistryLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "id.yaml"), []byte("type: string\npattern: ^[0-9]+$\n"), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "doc.json"), []byte(`{"type":"object","properties":{"id":{"$ref":"id.yaml"}}}`), 0600))
+
+	registry := NewSchemaRegistry()
+	assert.Nil(t, registry.RegisterFile(filepath.Join(dir, "doc.json")))
+
+	validator, err := registry.Validator("file://" + filepath.ToSlash(filepath.Join(dir, "doc.json")))
+	assert.Nil(t, err)
+	assert.Nil(t, validator.Validate(MustNewFromString(`{"id":"123"}`)))
+	assert.NotNil(t, validator.Validate(MustNewFromString(`{"id":"abc"}`)))
+
+	// compiled schema is self-contained
+	assert.True(t, validator.Schema().MustExistsJPtr("/$defs/ref0/pattern"))
+}
+
+func TestValidatorConcurrent(t *testing.T) {
+	validator, err := CompileSchema(MustNewFromString(`{"type":"object","properties":{"child":{"$ref":"#/definitions/child"}},"definitions":{"child":{"type":"object","required":["name"]}}}`))
+	assert.Nil(t, err)
+
+	wg := sync.WaitGroup{}
+	for index := 0; index < 20; index++ {
+		wg.Add(1)
+		go func(valid bool) {
+			defer wg.Done()
+			for iteration := 0; iteration < 50; iteration++ {
+				if valid {
+					assert.Nil(t, validator.Validate(MustNewFromString(`{"child":{"name":"x"}}`)))
+				} else {
+					assert.NotNil(t, validator.Validate(MustNewFromString(`{"child":{}}`)))
+				}
+			}
+		}(index%2 == 0)
+	}
+	wg.Wait()
+}
+
+func TestSchemaRegistryConcurrent(t *testing.T) {
+	registry := NewSchemaRegistry()
+	assert.Nil(t, registry.Register(MustNewFromString(`{"$id":"https://example.com/name.json","type":"string"}`)))
+	assert.Nil(t, registry.Register(MustNewFromString(`{"$id":"https://example.com/doc.json","type":"object","properties":{"name":{"$ref":"name.json"}}}`)))
+
+	validators := make([]*Validator, 20)
+	wg := sync.WaitGroup{}
+	for index := range validators {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			validator, err := registry.Validator("https://example.com/doc.json")
+			assert.Nil(t, err)
+			validators[index] = validator
+		}(index)
+	}
+	wg.Wait()
+
+	// all callers share cached instance
+	for _, validator := range validators {
+		assert.True(t, validators[0] == validator)
+	}
+
+	// registration racing with compilation never leaves validator built from replaced schema
+	for iteration := 0; iteration < 20; iteration++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := registry.Validator("https://example.com/doc.json")
+			assert.Nil(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, registry.Register(MustNewFromString(`{"$id":"https://example.com/name.json","type":"integer"}`)))
+		}()
+		wg.Wait()
+
+		validator, err := registry.Validator("https://example.com/doc.json")
+		assert.Nil(t, err)
+		assert.Nil(t, validator.Validate(MustNewFromString(`{"name":1}`)))
+		assert.NotNil(t, validator.Validate(MustNewFromString(`{"name":"x"}`)))
+	}
+}
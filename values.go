@@ -0,0 +1,151 @@
+package rmap
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/shopspring/decimal"
+)
+
+// asObject returns node as map if it is JSON object
+func asObject(node interface{}) (map[string]interface{}, bool) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		return v, true
+	case Rmap:
+		return v.Mapa, true
+	}
+
+	rv := reflect.ValueOf(node)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	obj := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		obj[iter.Key().String()] = iter.Value().Interface()
+	}
+
+	return obj, true
+}
+
+// asArray returns node as []interface{} if it is JSON array
+func asArray(node interface{}) ([]interface{}, bool) {
+	switch v := node.(type) {
+	case []interface{}:
+		return v, true
+	case []byte:
+		// marshalled as string
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(node)
+	if rv.Kind() != reflect.Slice {
+		return nil, false
+	}
+
+	arr := make([]interface{}, rv.Len())
+	for index := range arr {
+		arr[index] = rv.Index(index).Interface()
+	}
+
+	return arr, true
+}
+
+// asNumber returns node as float64 if it is JSON number
+func asNumber(node interface{}) (float64, bool) {
+	if num, ok := node.(json.Number); ok {
+		f, err := num.Float64()
+		return f, err == nil
+	}
+
+	rv := reflect.ValueOf(node)
+
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	default:
+		return 0, false
+	}
+}
+
+// asDecimal returns node as exact decimal if it is JSON number, NaN and infinities are not numbers
+func asDecimal(node interface{}) (decimal.Decimal, bool) {
+	if num, ok := node.(json.Number); ok {
+		d, err := decimal.NewFromString(num.String())
+		return d, err == nil
+	}
+
+	rv := reflect.ValueOf(node)
+
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(rv.Float()) || math.IsInf(rv.Float(), 0) {
+			return decimal.Zero, false
+		}
+		return decimal.NewFromFloat(rv.Float()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return decimal.NewFromInt(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return decimal.NewFromBigInt(new(big.Int).SetUint64(rv.Uint()), 0), true
+	default:
+		return decimal.Zero, false
+	}
+}
+
+// jsonEqual compares two JSON values, numbers are compared by value regardless of Go type
+func jsonEqual(a, b interface{}) bool {
+	if aN, ok := asNumber(a); ok {
+		bN, ok := asNumber(b)
+		return ok && aN == bN
+	}
+
+	if aO, ok := asObject(a); ok {
+		bO, ok := asObject(b)
+		if !ok || len(aO) != len(bO) {
+			return false
+		}
+
+		for key, aV := range aO {
+			bV, exists := bO[key]
+			if !exists || !jsonEqual(aV, bV) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if aA, ok := asArray(a); ok {
+		bA, ok := asArray(b)
+		if !ok || len(aA) != len(bA) {
+			return false
+		}
+
+		for index := range aA {
+			if !jsonEqual(aA[index], bA[index]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch aV := a.(type) {
+	case nil:
+		return b == nil
+	case string:
+		bV, ok := b.(string)
+		return ok && aV == bV
+	case bool:
+		bV, ok := b.(bool)
+		return ok && aV == bV
+	}
+
+	return reflect.DeepEqual(a, b)
+}